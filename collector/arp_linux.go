@@ -20,15 +20,20 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type arpCollector struct {
-	entries *prometheus.Desc
-	logger  log.Logger
+	entries     *prometheus.Desc
+	gcThreshold *prometheus.Desc
+	tableFulls  *prometheus.Desc
+	logger      log.Logger
 }
 
 func init() {
@@ -43,6 +48,16 @@ func NewARPCollector(logger log.Logger) (Collector, error) {
 			"ARP entries by device",
 			[]string{"device"}, nil,
 		),
+		gcThreshold: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "arp", "gc_threshold_entries"),
+			"Garbage collection threshold of the ARP table from net.ipv4.neigh.default.gc_thresh<threshold>, no entries are added above gc_thresh3.",
+			[]string{"threshold"}, nil,
+		),
+		tableFulls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "neighbor", "table_overflow_total"),
+			"Number of times an ARP entry couldn't be added because the table was full.",
+			nil, nil,
+		),
 		logger: logger,
 	}, nil
 }
@@ -101,5 +116,66 @@ func (c *arpCollector) Update(ch chan<- prometheus.Metric) error {
 			c.entries, prometheus.GaugeValue, float64(entryCount), device)
 	}
 
+	for _, threshold := range []string{"1", "2", "3"} {
+		value, err := readUintFromFile(procFilePath(filepath.Join("sys/net/ipv4/neigh/default", "gc_thresh"+threshold)))
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't read ARP gc threshold", "threshold", threshold, "err", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			c.gcThreshold, prometheus.GaugeValue, float64(value), threshold)
+	}
+
+	tableFulls, err := getARPTableFulls()
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "Couldn't read ARP cache statistics", "err", err)
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.tableFulls, prometheus.CounterValue, float64(tableFulls))
+
 	return nil
 }
+
+func getARPTableFulls() (uint64, error) {
+	file, err := os.Open(procFilePath("net/stat/arp_cache"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return parseARPTableFulls(file)
+}
+
+// parseARPTableFulls sums the table_fulls column of the per CPU lines of
+// /proc/net/stat/arp_cache, the values are hexadecimal.
+func parseARPTableFulls(data io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(data)
+	if !scanner.Scan() {
+		return 0, fmt.Errorf("empty ARP cache statistics")
+	}
+	column := -1
+	for i, name := range strings.Fields(scanner.Text()) {
+		if name == "table_fulls" {
+			column = i
+		}
+	}
+	if column < 0 {
+		return 0, fmt.Errorf("no table_fulls in ARP cache statistics")
+	}
+
+	var sum uint64
+	for scanner.Scan() {
+		columns := strings.Fields(scanner.Text())
+		if len(columns) <= column {
+			return 0, fmt.Errorf("unexpected ARP cache statistics format")
+		}
+		value, err := strconv.ParseUint(columns[column], 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid table_fulls %q: %w", columns[column], err)
+		}
+		sum += value
+	}
+
+	return sum, scanner.Err()
+}
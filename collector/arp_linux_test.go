@@ -0,0 +1,71 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noarp
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseARPTableFulls(t *testing.T) {
+	const header = "entries  allocs   table_fulls\n"
+	for _, tc := range []struct {
+		name string
+		in   string
+		want uint64
+		err  bool
+	}{
+		{name: "per cpu lines", in: header + "00000006 0000002a 00000004\n00000006 00000011 0000000a\n", want: 14},
+		{name: "header only", in: header, want: 0},
+		{name: "empty", in: "", err: true},
+		{name: "no table_fulls column", in: "entries  allocs\n00000006 0000002a\n", err: true},
+		{name: "short row", in: header + "00000006 0000002a 00000004\n00000006 00000011\n", err: true},
+		{name: "malformed value", in: header + "00000006 0000002a 0000000g\n", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseARPTableFulls(strings.NewReader(tc.in))
+			if tc.err {
+				if err == nil {
+					t.Fatalf("want an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseARPTableFullsFixture(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/stat/arp_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	got, err := parseARPTableFulls(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(7); got != want {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
@@ -16,9 +16,12 @@
 package collector
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/procfs/bcache"
 )
@@ -50,6 +53,11 @@ func NewBcacheCollector(logger log.Logger) (Collector, error) {
 // Update reads and exposes bcache stats.
 // It implements the Collector interface.
 func (c *bcacheCollector) Update(ch chan<- prometheus.Metric) error {
+	if _, err := os.Stat(sysFilePath("fs/bcache")); errors.Is(err, os.ErrNotExist) {
+		level.Debug(c.logger).Log("msg", "bcache statistics not found, skipping", "err", err)
+		return ErrNoData
+	}
+
 	stats, err := c.fs.Stats()
 	if err != nil {
 		return fmt.Errorf("failed to retrieve bcache stats: %w", err)
@@ -0,0 +1,106 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cachingCollector replays the metrics of a successful update of the wrapped
+// Collector until ttl has passed, instead of updating it on every scrape.
+type cachingCollector struct {
+	collector Collector
+	ttl       time.Duration
+	// hitDesc describes a gauge that is 1 if the metrics came from the cache.
+	hitDesc *prometheus.Desc
+	now     func() time.Time
+
+	mtx     sync.Mutex
+	metrics []prometheus.Metric
+	expires time.Time
+}
+
+func newCachingCollector(c Collector, ttl time.Duration, hitDesc *prometheus.Desc) *cachingCollector {
+	return &cachingCollector{
+		collector: c,
+		ttl:       ttl,
+		hitDesc:   hitDesc,
+		now:       time.Now,
+	}
+}
+
+func (c *cachingCollector) Update(ch chan<- prometheus.Metric) error {
+	return c.UpdateContext(context.Background(), ch)
+}
+
+// UpdateContext implements ContextCollector. Concurrent scrapes wait for a
+// running update and are then served from its result. The metrics are sent
+// without holding the lock so a slow scrape doesn't block the others.
+func (c *cachingCollector) UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	metrics, hit, err := c.metricsContext(ctx)
+	for _, m := range metrics {
+		ch <- m
+	}
+	if err != nil {
+		return err
+	}
+	if hit {
+		ch <- prometheus.MustNewConstMetric(c.hitDesc, prometheus.GaugeValue, 1)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.hitDesc, prometheus.GaugeValue, 0)
+	}
+	return nil
+}
+
+// metricsContext returns the cached metrics, or updates the wrapped Collector
+// if they expired. hit is true if the metrics came from the cache.
+func (c *cachingCollector) metricsContext(ctx context.Context) (metrics []prometheus.Metric, hit bool, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.metrics != nil && c.now().Before(c.expires) {
+		// The cached slice is never modified, only replaced.
+		return c.metrics, true, nil
+	}
+
+	metrics = []prometheus.Metric{}
+	updateCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range updateCh {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	if cc, ok := c.collector.(ContextCollector); ok {
+		err = cc.UpdateContext(ctx, updateCh)
+	} else {
+		err = c.collector.Update(updateCh)
+	}
+	close(updateCh)
+	<-done
+
+	if err != nil {
+		// Failed updates are retried on the next scrape.
+		return metrics, false, err
+	}
+	c.metrics = metrics
+	c.expires = c.now().Add(c.ttl)
+	return metrics, false, nil
+}
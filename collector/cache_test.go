@@ -0,0 +1,116 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// countingCollector reports how often it was updated.
+type countingCollector struct {
+	updates int
+	err     error
+}
+
+var (
+	countingDesc = prometheus.NewDesc("test_updates", "Number of updates.", nil, nil)
+	cacheHitDesc = prometheus.NewDesc("test_cache_hit", "Cache hit.", nil, nil)
+)
+
+func (c *countingCollector) Update(ch chan<- prometheus.Metric) error {
+	c.updates++
+	ch <- prometheus.MustNewConstMetric(countingDesc, prometheus.GaugeValue, float64(c.updates))
+	return c.err
+}
+
+func TestCachingCollector(t *testing.T) {
+	counting := &countingCollector{}
+	now := time.Unix(0, 0)
+	c := newCachingCollector(counting, 10*time.Second, cacheHitDesc)
+	c.now = func() time.Time { return now }
+
+	for i, scrape := range []struct {
+		after   time.Duration
+		err     error
+		updates float64
+		hit     float64
+	}{
+		{0, nil, 1, 0},
+		{5 * time.Second, nil, 1, 1},
+		{5 * time.Second, nil, 2, 0},
+		{11 * time.Second, errors.New("failed"), 3, -1},
+		{time.Second, nil, 4, 0},
+		{time.Second, nil, 4, 1},
+	} {
+		now = now.Add(scrape.after)
+		counting.err = scrape.err
+
+		ch := make(chan prometheus.Metric, 10)
+		if err := c.Update(ch); err != scrape.err {
+			t.Fatalf("scrape %d: expected error %v, got %v", i, scrape.err, err)
+		}
+		close(ch)
+
+		got := map[*prometheus.Desc]float64{}
+		for m := range ch {
+			var pb dto.Metric
+			if err := m.Write(&pb); err != nil {
+				t.Fatal(err)
+			}
+			got[m.Desc()] = pb.GetGauge().GetValue()
+		}
+
+		if got[countingDesc] != scrape.updates {
+			t.Errorf("scrape %d: expected updates %v, got %v", i, scrape.updates, got[countingDesc])
+		}
+		hit, ok := got[cacheHitDesc]
+		if scrape.hit < 0 {
+			if ok {
+				t.Errorf("scrape %d: unexpected cache hit metric on failed update", i)
+			}
+		} else if hit != scrape.hit {
+			t.Errorf("scrape %d: expected cache hit %v, got %v", i, scrape.hit, hit)
+		}
+	}
+}
+
+func TestCachingCollectorSlowScrape(t *testing.T) {
+	c := newCachingCollector(&countingCollector{}, time.Minute, cacheHitDesc)
+	if err := c.Update(make(chan prometheus.Metric, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads from slow, so this scrape blocks on its first send.
+	slow := make(chan prometheus.Metric)
+	go c.Update(slow)
+
+	done := make(chan error)
+	go func() { done <- c.Update(make(chan prometheus.Metric, 10)) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scrape blocked by a slow concurrent scrape")
+	}
+	for range [2]struct{}{} {
+		<-slow
+	}
+}
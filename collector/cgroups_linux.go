@@ -0,0 +1,144 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocgroups
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const cgroupsSubsystem = "cgroups"
+
+type cgroupsCollector struct {
+	total   typedDesc
+	enabled typedDesc
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector(cgroupsSubsystem, defaultDisabled, NewCgroupsCollector)
+}
+
+// NewCgroupsCollector returns a new Collector exposing a summary of the
+// cgroup v2 unified hierarchy.
+func NewCgroupsCollector(logger log.Logger) (Collector, error) {
+	return &cgroupsCollector{
+		total: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cgroupsSubsystem, "total"),
+			"Number of active cgroups below the root of the unified hierarchy.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		enabled: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cgroupsSubsystem, "enabled"),
+			"Whether the controller is enabled for the children of the root cgroup.",
+			[]string{"controller"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *cgroupsCollector) Update(ch chan<- prometheus.Metric) error {
+	root, err := cgroupsUnifiedRoot()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "cgroup v2 hierarchy not found, skipping", "err", err)
+			return ErrNoData
+		}
+		return err
+	}
+
+	stat, err := parseCgroupStat(filepath.Join(root, "cgroup.stat"))
+	if err != nil {
+		return fmt.Errorf("couldn't get cgroup stats: %w", err)
+	}
+	ch <- c.total.mustNewConstMetric(float64(stat["nr_descendants"]))
+
+	controllers, err := readCgroupControllers(filepath.Join(root, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("couldn't get cgroup controllers: %w", err)
+	}
+	subtree, err := readCgroupControllers(filepath.Join(root, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("couldn't get cgroup subtree controllers: %w", err)
+	}
+	enabled := make(map[string]bool, len(subtree))
+	for _, controller := range subtree {
+		enabled[controller] = true
+	}
+	for _, controller := range controllers {
+		value := 0.0
+		if enabled[controller] {
+			value = 1
+		}
+		ch <- c.enabled.mustNewConstMetric(value, controller)
+	}
+
+	return nil
+}
+
+// cgroupsUnifiedRoot returns the root of the cgroup v2 hierarchy. Systems in
+// hybrid mode mount it below the v1 controllers, pure v1 systems don't have
+// one at all.
+func cgroupsUnifiedRoot() (string, error) {
+	var err error
+	for _, root := range []string{sysFilePath("fs/cgroup"), sysFilePath("fs/cgroup/unified")} {
+		if _, err = os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+			return root, nil
+		}
+	}
+	return "", err
+}
+
+func readCgroupControllers(file string) ([]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+func parseCgroupStat(file string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line in %s: %q", file, scanner.Text())
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %w", file, err)
+		}
+		stat[fields[0]] = value
+	}
+	return stat, scanner.Err()
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocgroups
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCgroupStat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cgroup.stat")
+	if err := ioutil.WriteFile(file, []byte("nr_descendants 112\nnr_dying_descendants 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := parseCgroupStat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(112), stat["nr_descendants"]; want != got {
+		t.Errorf("want nr_descendants %d, got %d", want, got)
+	}
+	if want, got := uint64(3), stat["nr_dying_descendants"]; want != got {
+		t.Errorf("want nr_dying_descendants %d, got %d", want, got)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("nr_descendants\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseCgroupStat(file); err == nil {
+		t.Error("expected an error for a line without value")
+	}
+}
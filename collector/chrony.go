@@ -0,0 +1,234 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nochrony
+
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	chronySubsystem = "chrony"
+
+	// Constants of the chronyd command and monitoring protocol, see candm.h
+	// in the chrony sources.
+	chronyProtocolVersion = 6
+	chronyPktTypeRequest  = 1
+	chronyPktTypeReply    = 2
+	chronyReqTracking     = 33
+	chronyRpyTracking     = 5
+	chronyStatusSuccess   = 0
+	// chronyd drops requests that are shorter than their reply, so the
+	// tracking request is padded to the length of the tracking reply.
+	chronyTrackingReplyLength = 28 + 80
+)
+
+var (
+	chronyAddress = kingpin.Flag("collector.chrony.address", "Address of the chronyd command and monitoring socket.").Default("127.0.0.1:323").String()
+)
+
+type chronyCollector struct {
+	address                                     string
+	offset, stratum, rootDispersion, leapStatus typedDesc
+	logger                                      log.Logger
+}
+
+type chronyRequestHeader struct {
+	Version  uint8
+	PktType  uint8
+	Res1     uint8
+	Res2     uint8
+	Command  uint16
+	Attempt  uint16
+	Sequence uint32
+	Pad1     uint32
+	Pad2     uint32
+}
+
+type chronyReplyHeader struct {
+	Version  uint8
+	PktType  uint8
+	Res1     uint8
+	Res2     uint8
+	Command  uint16
+	Reply    uint16
+	Status   uint16
+	Pad1     uint16
+	Pad2     uint16
+	Pad3     uint16
+	Sequence uint32
+	Pad4     uint32
+	Pad5     uint32
+}
+
+// chronyFloat is the 32 bit floating point format of the protocol, with a
+// 7 bit exponent and a 25 bit coefficient.
+type chronyFloat uint32
+
+func (f chronyFloat) Float64() float64 {
+	exp := int32(f >> 25)
+	if exp >= 1<<6 {
+		exp -= 1 << 7
+	}
+	coef := int32(f % (1 << 25))
+	if coef >= 1<<24 {
+		coef -= 1 << 25
+	}
+	return float64(coef) * math.Pow(2, float64(exp-25))
+}
+
+type chronyTracking struct {
+	RefID              uint32
+	IPAddr             [16]byte
+	IPFamily           uint16
+	IPPad              uint16
+	Stratum            uint16
+	LeapStatus         uint16
+	RefTimeSecHigh     uint32
+	RefTimeSecLow      uint32
+	RefTimeNsec        uint32
+	CurrentCorrection  chronyFloat
+	LastOffset         chronyFloat
+	RMSOffset          chronyFloat
+	FreqPPM            chronyFloat
+	ResidFreqPPM       chronyFloat
+	SkewPPM            chronyFloat
+	RootDelay          chronyFloat
+	RootDispersion     chronyFloat
+	LastUpdateInterval chronyFloat
+}
+
+func init() {
+	registerCollector(chronySubsystem, defaultDisabled, NewChronyCollector)
+}
+
+// NewChronyCollector returns a new Collector exposing the tracking state of
+// chronyd.
+func NewChronyCollector(logger log.Logger) (Collector, error) {
+	return &chronyCollector{
+		address: *chronyAddress,
+		offset: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, chronySubsystem, "offset_seconds"),
+			"Correction chronyd applies to the system clock, positive if the system clock is slow of NTP time.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		stratum: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, chronySubsystem, "stratum"),
+			"Stratum of chronyd.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		rootDispersion: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, chronySubsystem, "root_dispersion_seconds"),
+			"Total dispersion accumulated through all computers back to the stratum 1 computer.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		leapStatus: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, chronySubsystem, "leap_status"),
+			"Leap status of chronyd: 0 normal, 1 insert second, 2 delete second, 3 not synchronised.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *chronyCollector) Update(ch chan<- prometheus.Metric) error {
+	tracking, err := queryChronyTracking(c.address, time.Second)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			level.Debug(c.logger).Log("msg", "chronyd is not running, skipping", "address", c.address, "err", err)
+			return ErrNoData
+		}
+		return fmt.Errorf("couldn't get chrony tracking: %w", err)
+	}
+
+	ch <- c.offset.mustNewConstMetric(tracking.CurrentCorrection.Float64())
+	ch <- c.stratum.mustNewConstMetric(float64(tracking.Stratum))
+	ch <- c.rootDispersion.mustNewConstMetric(tracking.RootDispersion.Float64())
+	ch <- c.leapStatus.mustNewConstMetric(float64(tracking.LeapStatus))
+	return nil
+}
+
+func queryChronyTracking(address string, timeout time.Duration) (chronyTracking, error) {
+	var tracking chronyTracking
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return tracking, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return tracking, err
+	}
+
+	seq := rand.Uint32()
+	req := make([]byte, chronyTrackingReplyLength)
+	buf := bytes.NewBuffer(req[:0])
+	if err := binary.Write(buf, binary.BigEndian, chronyRequestHeader{
+		Version:  chronyProtocolVersion,
+		PktType:  chronyPktTypeRequest,
+		Command:  chronyReqTracking,
+		Sequence: seq,
+	}); err != nil {
+		return tracking, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return tracking, err
+	}
+
+	resp := make([]byte, 1024)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return tracking, err
+	}
+	return parseChronyTrackingReply(resp[:n], seq)
+}
+
+func parseChronyTrackingReply(b []byte, seq uint32) (chronyTracking, error) {
+	var (
+		header   chronyReplyHeader
+		tracking chronyTracking
+	)
+	r := bytes.NewReader(b)
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return tracking, fmt.Errorf("invalid reply header: %w", err)
+	}
+	switch {
+	case header.Version != chronyProtocolVersion:
+		return tracking, fmt.Errorf("unsupported protocol version %d", header.Version)
+	case header.PktType != chronyPktTypeReply, header.Sequence != seq:
+		return tracking, errors.New("unexpected reply packet")
+	case header.Status != chronyStatusSuccess:
+		return tracking, fmt.Errorf("request failed with status %d", header.Status)
+	case header.Reply != chronyRpyTracking:
+		return tracking, fmt.Errorf("unexpected reply type %d", header.Reply)
+	}
+	if err := binary.Read(r, binary.BigEndian, &tracking); err != nil {
+		return tracking, fmt.Errorf("invalid tracking reply: %w", err)
+	}
+	return tracking, nil
+}
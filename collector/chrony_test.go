@@ -0,0 +1,113 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nochrony
+
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestChronyFloat(t *testing.T) {
+	for _, tc := range []struct {
+		in   chronyFloat
+		want float64
+	}{
+		{in: 0, want: 0},
+		{in: 1<<25 | 1<<23, want: 0.5},
+		{in: 1<<25 | (1<<25 - 1<<23), want: -0.5},
+		{in: 120<<25 | 1<<23, want: 1.0 / 1024},
+	} {
+		if got := tc.in.Float64(); got != tc.want {
+			t.Errorf("%#x: want %v, got %v", uint32(tc.in), tc.want, got)
+		}
+	}
+}
+
+// serveChronyTracking answers one tracking request on conn.
+func serveChronyTracking(t *testing.T, conn net.PacketConn, tracking chronyTracking) {
+	req := make([]byte, 1024)
+	n, addr, err := conn.ReadFrom(req)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var header chronyRequestHeader
+	if err := binary.Read(bytes.NewReader(req[:n]), binary.BigEndian, &header); err != nil {
+		t.Error(err)
+		return
+	}
+	if n != chronyTrackingReplyLength || header.Command != chronyReqTracking {
+		t.Errorf("unexpected request of %d bytes for command %d", n, header.Command)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, chronyReplyHeader{
+		Version:  chronyProtocolVersion,
+		PktType:  chronyPktTypeReply,
+		Command:  header.Command,
+		Reply:    chronyRpyTracking,
+		Sequence: header.Sequence,
+	})
+	binary.Write(&buf, binary.BigEndian, tracking)
+	binary.Write(&buf, binary.BigEndian, uint32(0))
+	if _, err := conn.WriteTo(buf.Bytes(), addr); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryChronyTracking(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	want := chronyTracking{
+		Stratum:           3,
+		LeapStatus:        1,
+		CurrentCorrection: 1<<25 | 1<<23,
+		RootDispersion:    120<<25 | 1<<23,
+	}
+	go serveChronyTracking(t, conn, want)
+
+	got, err := queryChronyTracking(conn.LocalAddr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestChronyCollectorNotRunning(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := conn.LocalAddr().String()
+	conn.Close()
+
+	c := &chronyCollector{address: address, logger: log.NewNopLogger()}
+	if err := c.Update(make(chan prometheus.Metric, 4)); err != ErrNoData {
+		t.Fatalf("want ErrNoData, got %v", err)
+	}
+}
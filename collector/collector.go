@@ -15,8 +15,10 @@
 package collector
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"sync"
 	"time"
 
@@ -26,23 +28,15 @@ import (
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
-// Namespace defines the common namespace to be used by all metrics.
-const namespace = "node"
+// namespace defines the common namespace to be used by all metrics. It is set
+// from --collector.namespace when the command line is parsed.
+var namespace = "node"
 
-var (
-	scrapeDurationDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
-		"node_exporter: Duration of a collector scrape.",
-		[]string{"collector"},
-		nil,
-	)
-	scrapeSuccessDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "scrape", "collector_success"),
-		"node_exporter: Whether a collector succeeded.",
-		[]string{"collector"},
-		nil,
-	)
-)
+var namespaceRE = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+func init() {
+	kingpin.Flag("collector.namespace", "Namespace (metric name prefix) used for all metrics.").Default(namespace).StringVar(&namespace)
+}
 
 const (
 	defaultEnabled  = true
@@ -75,8 +69,11 @@ func registerCollector(collector string, isDefaultEnabled bool, factory func(log
 
 // NodeCollector implements the prometheus.Collector interface.
 type NodeCollector struct {
-	Collectors map[string]Collector
-	logger     log.Logger
+	Collectors         map[string]Collector
+	logger             log.Logger
+	ctx                context.Context
+	scrapeDurationDesc *prometheus.Desc
+	scrapeSuccessDesc  *prometheus.Desc
 }
 
 // DisableDefaultCollectors sets the collector state to false for all collectors which
@@ -103,6 +100,9 @@ func collectorFlagAction(collector string) func(ctx *kingpin.ParseContext) error
 
 // NewNodeCollector creates a new NodeCollector.
 func NewNodeCollector(logger log.Logger, filters ...string) (*NodeCollector, error) {
+	if !namespaceRE.MatchString(namespace) {
+		return nil, fmt.Errorf("invalid namespace: %q", namespace)
+	}
 	f := make(map[string]bool)
 	for _, filter := range filters {
 		enabled, exist := collectorState[filter]
@@ -126,47 +126,81 @@ func NewNodeCollector(logger log.Logger, filters ...string) (*NodeCollector, err
 			}
 		}
 	}
-	return &NodeCollector{Collectors: collectors, logger: logger}, nil
+	return &NodeCollector{
+		Collectors: collectors,
+		logger:     logger,
+		scrapeDurationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+			"node_exporter: Duration of a collector scrape.",
+			[]string{"collector"},
+			nil,
+		),
+		scrapeSuccessDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "collector_success"),
+			"node_exporter: Whether a collector succeeded.",
+			[]string{"collector"},
+			nil,
+		),
+	}, nil
+}
+
+// WithContext returns a shallow copy of n whose scrapes are bound to ctx.
+// Collectors implementing ContextCollector get ctx passed to UpdateContext.
+func (n *NodeCollector) WithContext(ctx context.Context) *NodeCollector {
+	nc := *n
+	nc.ctx = ctx
+	return &nc
 }
 
 // Describe implements the prometheus.Collector interface.
 func (n NodeCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- scrapeDurationDesc
-	ch <- scrapeSuccessDesc
+	ch <- n.scrapeDurationDesc
+	ch <- n.scrapeSuccessDesc
 }
 
 // Collect implements the prometheus.Collector interface.
 func (n NodeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := n.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	wg := sync.WaitGroup{}
 	wg.Add(len(n.Collectors))
 	for name, c := range n.Collectors {
 		go func(name string, c Collector) {
-			execute(name, c, ch, n.logger)
+			n.execute(ctx, name, c, ch)
 			wg.Done()
 		}(name, c)
 	}
 	wg.Wait()
 }
 
-func execute(name string, c Collector, ch chan<- prometheus.Metric, logger log.Logger) {
+func (n NodeCollector) execute(ctx context.Context, name string, c Collector, ch chan<- prometheus.Metric) {
+	var err error
 	begin := time.Now()
-	err := c.Update(ch)
+	if cc, ok := c.(ContextCollector); ok {
+		err = cc.UpdateContext(ctx, ch)
+	} else {
+		err = c.Update(ch)
+	}
 	duration := time.Since(begin)
 	var success float64
 
 	if err != nil {
 		if IsNoDataError(err) {
-			level.Debug(logger).Log("msg", "collector returned no data", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			// Having nothing to report is not a failure.
+			level.Debug(n.logger).Log("msg", "collector returned no data", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			success = 1
 		} else {
-			level.Error(logger).Log("msg", "collector failed", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			level.Error(n.logger).Log("msg", "collector failed", "name", name, "duration_seconds", duration.Seconds(), "err", err)
+			success = 0
 		}
-		success = 0
 	} else {
-		level.Debug(logger).Log("msg", "collector succeeded", "name", name, "duration_seconds", duration.Seconds())
+		level.Debug(n.logger).Log("msg", "collector succeeded", "name", name, "duration_seconds", duration.Seconds())
 		success = 1
 	}
-	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
-	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	ch <- prometheus.MustNewConstMetric(n.scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(n.scrapeSuccessDesc, prometheus.GaugeValue, success, name)
 }
 
 // Collector is the interface a collector has to implement.
@@ -175,6 +209,15 @@ type Collector interface {
 	Update(ch chan<- prometheus.Metric) error
 }
 
+// ContextCollector is an optional interface for collectors that can abort an
+// update when the scrape is canceled or times out. The node collector prefers
+// UpdateContext over Update for collectors implementing it.
+type ContextCollector interface {
+	Collector
+	// Like Update, but ctx is canceled once the scrape is no longer wanted.
+	UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error
+}
+
 type typedDesc struct {
 	desc      *prometheus.Desc
 	valueType prometheus.ValueType
@@ -188,5 +231,5 @@ func (d *typedDesc) mustNewConstMetric(value float64, labels ...string) promethe
 var ErrNoData = errors.New("collector returned no data")
 
 func IsNoDataError(err error) bool {
-	return err == ErrNoData
+	return errors.Is(err, ErrNoData)
 }
@@ -0,0 +1,86 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestNamespaceFlag(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.namespace", "custom"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	if namespace != "custom" {
+		t.Fatalf("namespace = %q, want %q", namespace, "custom")
+	}
+
+	c := newTextFileCollector("fixtures/textfile/no_metric_files", 0, log.NewNopLogger())
+	want := `# HELP custom_textfile_scrape_error 1 if there was an error opening or reading a file, 0 otherwise
+# TYPE custom_textfile_scrape_error gauge
+custom_textfile_scrape_error 0
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNamespaceFlagDefault(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "node" {
+		t.Fatalf("namespace = %q, want %q", namespace, "node")
+	}
+}
+
+func TestNamespaceValidation(t *testing.T) {
+	for _, tc := range []struct {
+		namespace string
+		valid     bool
+	}{
+		{"node", true},
+		{"my_node", true},
+		{"_node2", true},
+		{"Node", true},
+		{"", false},
+		{"2node", false},
+		{"my-node", false},
+		{"my.node", false},
+		{"my node", false},
+	} {
+		t.Run(tc.namespace, func(t *testing.T) {
+			if got := namespaceRE.MatchString(tc.namespace); got != tc.valid {
+				t.Errorf("namespaceRE.MatchString(%q) = %v, want %v", tc.namespace, got, tc.valid)
+			}
+		})
+	}
+}
+
+func TestNewNodeCollectorInvalidNamespace(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.namespace", "my-node"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	if _, err := NewNodeCollector(log.NewNopLogger()); err == nil {
+		t.Fatal("expected an error for an invalid namespace")
+	}
+}
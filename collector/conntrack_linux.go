@@ -16,14 +16,38 @@
 package collector
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type conntrackCollector struct {
 	current *prometheus.Desc
 	limit   *prometheus.Desc
-	logger  log.Logger
+	// stats maps columns of /proc/net/stat/nf_conntrack to their descs.
+	stats  map[string]*prometheus.Desc
+	logger log.Logger
+}
+
+// conntrackStats are the columns of /proc/net/stat/nf_conntrack that are
+// exported, with their help texts.
+var conntrackStats = map[string]string{
+	"found":          "Number of searched entries which were successful.",
+	"invalid":        "Number of packets seen which can not be tracked.",
+	"ignore":         "Number of packets seen which are already connected to a conntrack entry.",
+	"insert":         "Number of entries inserted into the list.",
+	"insert_failed":  "Number of entries for which list insertion was attempted but failed.",
+	"drop":           "Number of packets dropped due to conntrack failure.",
+	"early_drop":     "Number of dropped conntrack entries to make room for new ones, if maximum table size was reached.",
+	"search_restart": "Number of conntrack table lookups which had to be restarted due to hashtable resizes.",
 }
 
 func init() {
@@ -32,6 +56,14 @@ func init() {
 
 // NewConntrackCollector returns a new Collector exposing conntrack stats.
 func NewConntrackCollector(logger log.Logger) (Collector, error) {
+	stats := make(map[string]*prometheus.Desc, len(conntrackStats))
+	for name, help := range conntrackStats {
+		stats[name] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "nf_conntrack_stat_"+name+"_total"),
+			help, nil, nil,
+		)
+	}
+
 	return &conntrackCollector{
 		current: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "nf_conntrack_entries"),
@@ -43,6 +75,7 @@ func NewConntrackCollector(logger log.Logger) (Collector, error) {
 			"Maximum size of connection tracking table.",
 			nil, nil,
 		),
+		stats:  stats,
 		logger: logger,
 	}, nil
 }
@@ -50,8 +83,8 @@ func NewConntrackCollector(logger log.Logger) (Collector, error) {
 func (c *conntrackCollector) Update(ch chan<- prometheus.Metric) error {
 	value, err := readUintFromFile(procFilePath("sys/net/netfilter/nf_conntrack_count"))
 	if err != nil {
-		// Conntrack probably not loaded into the kernel.
-		return nil
+		level.Debug(c.logger).Log("msg", "conntrack probably not loaded into the kernel", "err", err)
+		return ErrNoData
 	}
 	ch <- prometheus.MustNewConstMetric(
 		c.current, prometheus.GaugeValue, float64(value))
@@ -63,5 +96,55 @@ func (c *conntrackCollector) Update(ch chan<- prometheus.Metric) error {
 	ch <- prometheus.MustNewConstMetric(
 		c.limit, prometheus.GaugeValue, float64(value))
 
+	stats, err := getConntrackStats()
+	if errors.Is(err, os.ErrNotExist) {
+		level.Debug(c.logger).Log("msg", "conntrack statistics not found, skipping", "err", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get conntrack statistics: %w", err)
+	}
+	for name, desc := range c.stats {
+		if v, ok := stats[name]; ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+		}
+	}
+
 	return nil
 }
+
+func getConntrackStats() (map[string]float64, error) {
+	file, err := os.Open(procFilePath("net/stat/nf_conntrack"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseConntrackStats(file)
+}
+
+// parseConntrackStats sums up the per cpu lines of /proc/net/stat/nf_conntrack
+// by the column names in the header, as the columns differ between kernels.
+func parseConntrackStats(r io.Reader) (map[string]float64, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, errors.New("conntrack statistics empty")
+	}
+	header := strings.Fields(scanner.Text())
+
+	stats := make(map[string]float64, len(header))
+	for scanner.Scan() {
+		values := strings.Fields(scanner.Text())
+		if len(values) != len(header) {
+			return nil, fmt.Errorf("invalid line in conntrack statistics: %q", scanner.Text())
+		}
+		for i, value := range values {
+			v, err := strconv.ParseUint(value, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s in conntrack statistics: %w", value, err)
+			}
+			stats[header[i]] += float64(v)
+		}
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noconntrack
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConntrackStats(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/stat/nf_conntrack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseConntrackStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]float64{
+		"found":          17,
+		"invalid":        5,
+		"insert_failed":  1,
+		"drop":           2,
+		"early_drop":     1,
+		"search_restart": 4,
+	} {
+		if got := stats[name]; want != got {
+			t.Errorf("want conntrack %s %v, got %v", name, want, got)
+		}
+	}
+
+	if _, err := parseConntrackStats(strings.NewReader("entries found\n00000001\n")); err == nil {
+		t.Error("expected an error for a line with missing columns")
+	}
+}
@@ -23,10 +23,12 @@ const (
 	cpuCollectorSubsystem = "cpu"
 )
 
-var (
-	nodeCPUSecondsDesc = prometheus.NewDesc(
+// nodeCPUSecondsDesc returns the descriptor shared by the cpu collectors. It
+// is built on demand since namespace isn't known before flags are parsed.
+func nodeCPUSecondsDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "seconds_total"),
 		"Seconds the cpus spent in each mode.",
 		[]string{"cpu", "mode"}, nil,
 	)
-)
+}
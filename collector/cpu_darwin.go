@@ -61,7 +61,7 @@ func init() {
 // NewCPUCollector returns a new Collector exposing CPU stats.
 func NewCPUCollector(logger log.Logger) (Collector, error) {
 	return &statCollector{
-		cpu:    nodeCPUSecondsDesc,
+		cpu:    nodeCPUSecondsDesc(),
 		logger: logger,
 	}, nil
 }
@@ -87,7 +87,7 @@ func init() {
 // NewStatCollector returns a new Collector exposing CPU stats.
 func NewStatCollector(logger log.Logger) (Collector, error) {
 	return &statCollector{
-		cpu:    nodeCPUSecondsDesc,
+		cpu:    nodeCPUSecondsDesc(),
 		logger: logger,
 	}, nil
 }
@@ -94,7 +94,7 @@ func init() {
 // NewStatCollector returns a new Collector exposing CPU stats.
 func NewStatCollector(logger log.Logger) (Collector, error) {
 	return &statCollector{
-		cpu: typedDesc{nodeCPUSecondsDesc, prometheus.CounterValue},
+		cpu: typedDesc{nodeCPUSecondsDesc(), prometheus.CounterValue},
 		temp: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "temperature_celsius"),
 			"CPU temperature",
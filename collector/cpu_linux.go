@@ -17,9 +17,11 @@ package collector
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/go-kit/kit/log"
@@ -38,6 +40,8 @@ type cpuCollector struct {
 	cpuGuest           *prometheus.Desc
 	cpuCoreThrottle    *prometheus.Desc
 	cpuPackageThrottle *prometheus.Desc
+	cpuVulnerability   *prometheus.Desc
+	cpuVulnerable      *prometheus.Desc
 	logger             log.Logger
 	cpuStats           []procfs.CPUStat
 	cpuStatsMutex      sync.Mutex
@@ -64,7 +68,7 @@ func NewCPUCollector(logger log.Logger) (Collector, error) {
 	}
 	c := &cpuCollector{
 		fs:  fs,
-		cpu: nodeCPUSecondsDesc,
+		cpu: nodeCPUSecondsDesc(),
 		cpuInfo: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "info"),
 			"CPU information from /proc/cpuinfo.",
@@ -95,6 +99,16 @@ func NewCPUCollector(logger log.Logger) (Collector, error) {
 			"Number of times this cpu package has been throttled.",
 			[]string{"package"}, nil,
 		),
+		cpuVulnerability: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "vulnerability"),
+			"Mitigation state of a CPU vulnerability from /sys/devices/system/cpu/vulnerabilities, value is always 1.",
+			[]string{"name", "state"}, nil,
+		),
+		cpuVulnerable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "vulnerable"),
+			"Whether the state of a CPU vulnerability contains \"Vulnerable\".",
+			[]string{"name"}, nil,
+		),
 		logger: logger,
 	}
 	err = c.compileIncludeFlags(flagsInclude, bugsInclude)
@@ -139,6 +153,9 @@ func (c *cpuCollector) Update(ch chan<- prometheus.Metric) error {
 	if err := c.updateThermalThrottle(ch); err != nil {
 		return err
 	}
+	if err := c.updateVulnerabilities(ch); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -191,6 +208,33 @@ func updateFieldInfo(valueList []string, filter *regexp.Regexp, desc *prometheus
 	return nil
 }
 
+// updateVulnerabilities reads /sys/devices/system/cpu/vulnerabilities and
+// exposes the free-form state of each vulnerability, like "Mitigation: PTI".
+func (c *cpuCollector) updateVulnerabilities(ch chan<- prometheus.Metric) error {
+	files, err := filepath.Glob(sysFilePath("devices/system/cpu/vulnerabilities/*"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't read CPU vulnerability", "file", file, "err", err)
+			continue
+		}
+		name := filepath.Base(file)
+		state := strings.TrimSpace(string(content))
+		ch <- prometheus.MustNewConstMetric(c.cpuVulnerability, prometheus.GaugeValue, 1, name, state)
+
+		vulnerable := 0.0
+		if strings.Contains(state, "Vulnerable") {
+			vulnerable = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.cpuVulnerable, prometheus.GaugeValue, vulnerable, name)
+	}
+	return nil
+}
+
 // updateThermalThrottle reads /sys/devices/system/cpu/cpu* and expose thermal throttle statistics.
 func (c *cpuCollector) updateThermalThrottle(ch chan<- prometheus.Metric) error {
 	cpus, err := filepath.Glob(sysFilePath("devices/system/cpu/cpu[0-9]*"))
@@ -41,7 +41,7 @@ func init() {
 
 func NewCPUCollector(logger log.Logger) (Collector, error) {
 	return &cpuCollector{
-		cpu:    typedDesc{nodeCPUSecondsDesc, prometheus.CounterValue},
+		cpu:    typedDesc{nodeCPUSecondsDesc(), prometheus.CounterValue},
 		logger: logger,
 	}, nil
 }
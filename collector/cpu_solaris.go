@@ -38,13 +38,15 @@ func init() {
 
 func NewCpuCollector(logger log.Logger) (Collector, error) {
 	return &cpuCollector{
-		cpu:    typedDesc{nodeCPUSecondsDesc, prometheus.CounterValue},
+		cpu:    typedDesc{nodeCPUSecondsDesc(), prometheus.CounterValue},
 		logger: logger,
 	}, nil
 }
 
 func (c *cpuCollector) Update(ch chan<- prometheus.Metric) error {
 	ncpus := C.sysconf(C._SC_NPROCESSORS_ONLN)
+	// cpu_ticks_* are counted in clock ticks.
+	clkTck := float64(C.sysconf(C._SC_CLK_TCK))
 
 	tok, err := kstat.Open()
 	if err != nil {
@@ -70,7 +72,7 @@ func (c *cpuCollector) Update(ch chan<- prometheus.Metric) error {
 				return err
 			}
 
-			ch <- c.cpu.mustNewConstMetric(float64(kstatValue.UintVal), strconv.Itoa(cpu), k)
+			ch <- c.cpu.mustNewConstMetric(float64(kstatValue.UintVal)/clkTck, strconv.Itoa(cpu), k)
 		}
 	}
 	return nil
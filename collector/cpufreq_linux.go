@@ -31,6 +31,7 @@ type cpuFreqCollector struct {
 	scalingFreq    *prometheus.Desc
 	scalingFreqMin *prometheus.Desc
 	scalingFreqMax *prometheus.Desc
+	governor       *prometheus.Desc
 	logger         log.Logger
 }
 
@@ -77,6 +78,11 @@ func NewCPUFreqCollector(logger log.Logger) (Collector, error) {
 			"Maximum scaled cpu thread frequency in hertz.",
 			[]string{"cpu"}, nil,
 		),
+		governor: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cpuCollectorSubsystem, "scaling_governor_info"),
+			"Scaling governor of the cpu thread, always 1.",
+			[]string{"cpu", "governor"}, nil,
+		),
 		logger: logger,
 	}, nil
 }
@@ -139,6 +145,14 @@ func (c *cpuFreqCollector) Update(ch chan<- prometheus.Metric) error {
 				stats.Name,
 			)
 		}
+		if stats.Governor != "" {
+			ch <- prometheus.MustNewConstMetric(
+				c.governor,
+				prometheus.GaugeValue,
+				1,
+				stats.Name, stats.Governor,
+			)
+		}
 	}
 	return nil
 }
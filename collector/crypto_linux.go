@@ -0,0 +1,130 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocrypto
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const cryptoSubsystem = "crypto"
+
+// cryptoAlgorithm is a record of /proc/crypto.
+type cryptoAlgorithm struct {
+	name, driver, module, algType string
+}
+
+type cryptoCollector struct {
+	driverInfo typedDesc
+	algorithms typedDesc
+	logger     log.Logger
+}
+
+func init() {
+	registerCollector(cryptoSubsystem, defaultDisabled, NewCryptoCollector)
+}
+
+// NewCryptoCollector returns a new Collector exposing the algorithms and
+// drivers of the kernel crypto API from /proc/crypto.
+func NewCryptoCollector(logger log.Logger) (Collector, error) {
+	return &cryptoCollector{
+		driverInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cryptoSubsystem, "driver_info"),
+			"Driver of an algorithm of the kernel crypto API, value is always 1.",
+			[]string{"name", "driver", "module", "type"}, nil,
+		), prometheus.GaugeValue},
+		algorithms: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, cryptoSubsystem, "algorithms"),
+			"Number of algorithm drivers of the kernel crypto API by type.",
+			[]string{"type"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *cryptoCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("crypto"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	algorithms, err := parseCrypto(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse crypto: %w", err)
+	}
+
+	types := map[string]int{}
+	seen := map[cryptoAlgorithm]bool{}
+	for _, a := range algorithms {
+		if seen[a] {
+			continue
+		}
+		seen[a] = true
+		types[a.algType]++
+		ch <- c.driverInfo.mustNewConstMetric(1, a.name, a.driver, a.module, a.algType)
+	}
+	for t, n := range types {
+		ch <- c.algorithms.mustNewConstMetric(float64(n), t)
+	}
+	return nil
+}
+
+// parseCrypto returns the records of /proc/crypto, which are separated by
+// blank lines and consist of "key : value" lines.
+func parseCrypto(r io.Reader) ([]cryptoAlgorithm, error) {
+	var (
+		algorithms []cryptoAlgorithm
+		current    cryptoAlgorithm
+		scanner    = bufio.NewScanner(r)
+	)
+	flush := func() {
+		if current != (cryptoAlgorithm{}) {
+			algorithms = append(algorithms, current)
+		}
+		current = cryptoAlgorithm{}
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line in crypto: %q", line)
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "name":
+			current.name = value
+		case "driver":
+			current.driver = value
+		case "module":
+			current.module = value
+		case "type":
+			current.algType = value
+		}
+	}
+	flush()
+	return algorithms, scanner.Err()
+}
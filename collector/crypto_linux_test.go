@@ -0,0 +1,53 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocrypto
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCrypto(t *testing.T) {
+	file, err := os.Open("fixtures/proc/crypto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	algorithms, err := parseCrypto(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cryptoAlgorithm{
+		{name: "ccm(aes)", driver: "ccm_base(ctr(aes-aesni),cbcmac(aes-aesni))", module: "ccm", algType: "aead"},
+		{name: "__xts(aes)", driver: "__xts-aes-aesni", module: "aesni_intel", algType: "skcipher"},
+		{name: "aes", driver: "aes-aesni", module: "aesni_intel", algType: "cipher"},
+		{name: "crc32c", driver: "crc32c-intel", module: "crc32c_intel", algType: "shash"},
+		{name: "sha256", driver: "sha256-generic", module: "kernel", algType: "shash"},
+		{name: "aes", driver: "aes-generic", module: "kernel", algType: "cipher"},
+	}
+	if !reflect.DeepEqual(algorithms, want) {
+		t.Errorf("want %+v, got %+v", want, algorithms)
+	}
+}
+
+func TestParseCryptoInvalid(t *testing.T) {
+	if _, err := parseCrypto(strings.NewReader("name aes\n")); err == nil {
+		t.Error("want error for line without separator")
+	}
+}
@@ -26,48 +26,65 @@ const (
 
 var (
 	diskLabelNames = []string{"device"}
+)
+
+// The disk descriptors below are shared by the diskstats collectors. They are
+// built on demand since namespace isn't known before flags are parsed.
 
-	readsCompletedDesc = prometheus.NewDesc(
+func readsCompletedDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "reads_completed_total"),
 		"The total number of reads completed successfully.",
 		diskLabelNames, nil,
 	)
+}
 
-	readBytesDesc = prometheus.NewDesc(
+func readBytesDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "read_bytes_total"),
 		"The total number of bytes read successfully.",
 		diskLabelNames, nil,
 	)
+}
 
-	writesCompletedDesc = prometheus.NewDesc(
+func writesCompletedDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "writes_completed_total"),
 		"The total number of writes completed successfully.",
 		diskLabelNames, nil,
 	)
+}
 
-	writtenBytesDesc = prometheus.NewDesc(
+func writtenBytesDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "written_bytes_total"),
 		"The total number of bytes written successfully.",
 		diskLabelNames, nil,
 	)
+}
 
-	ioTimeSecondsDesc = prometheus.NewDesc(
+func ioTimeSecondsDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "io_time_seconds_total"),
 		"Total seconds spent doing I/Os.",
 		diskLabelNames, nil,
 	)
+}
 
-	readTimeSecondsDesc = prometheus.NewDesc(
+func readTimeSecondsDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "read_time_seconds_total"),
 		"The total number of seconds spent by all reads.",
 		diskLabelNames,
 		nil,
 	)
+}
 
-	writeTimeSecondsDesc = prometheus.NewDesc(
+func writeTimeSecondsDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, diskSubsystem, "write_time_seconds_total"),
 		"This is the total number of seconds spent by all writes.",
 		diskLabelNames,
 		nil,
 	)
-)
+}
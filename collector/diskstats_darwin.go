@@ -45,7 +45,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 		descs: []typedDescFunc{
 			{
 				typedDesc: typedDesc{
-					desc:      readsCompletedDesc,
+					desc:      readsCompletedDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
@@ -68,7 +68,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 			},
 			{
 				typedDesc: typedDesc{
-					desc:      readTimeSecondsDesc,
+					desc:      readTimeSecondsDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
@@ -77,7 +77,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 			},
 			{
 				typedDesc: typedDesc{
-					desc:      writesCompletedDesc,
+					desc:      writesCompletedDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
@@ -100,7 +100,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 			},
 			{
 				typedDesc: typedDesc{
-					desc:      writeTimeSecondsDesc,
+					desc:      writeTimeSecondsDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
@@ -109,7 +109,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 			},
 			{
 				typedDesc: typedDesc{
-					desc:      readBytesDesc,
+					desc:      readBytesDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
@@ -118,7 +118,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 			},
 			{
 				typedDesc: typedDesc{
-					desc:      writtenBytesDesc,
+					desc:      writtenBytesDesc(),
 					valueType: prometheus.CounterValue,
 				},
 				value: func(stat *iostat.DriveStats) float64 {
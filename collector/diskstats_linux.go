@@ -23,6 +23,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -37,6 +38,17 @@ const (
 
 var (
 	ignoredDevices = kingpin.Flag("collector.diskstats.ignored-devices", "Regexp of devices to ignore for diskstats.").Default("^(ram|loop|fd|(h|s|v|xv)d[a-z]|nvme\\d+n\\d+p)\\d+$").String()
+	diskAvgLatency = kingpin.Flag("collector.diskstats.avg-latency", "Expose the average I/O latency of each device since the previous scrape.").Bool()
+	diskSkipIdle   = kingpin.Flag("collector.diskstats.skip-idle", "Skip devices that completed no reads or writes since node_exporter started.").Bool()
+)
+
+// Indices of the fields of a device in /proc/diskstats, after major, minor
+// and device name.
+const (
+	diskReadsCompleted  = 0
+	diskReadTimeMillis  = 3
+	diskWritesCompleted = 4
+	diskWriteTimeMillis = 7
 )
 
 type typedFactorDesc struct {
@@ -56,6 +68,24 @@ type diskstatsCollector struct {
 	ignoredDevicesPattern *regexp.Regexp
 	descs                 []typedFactorDesc
 	logger                log.Logger
+
+	// avgLatencyDesc is nil unless --collector.diskstats.avg-latency is set.
+	avgLatencyDesc *prometheus.Desc
+	mtx            sync.Mutex
+	lastLatency    map[string]diskLatencySample
+
+	// activeDevices holds the devices that completed I/O while running if
+	// --collector.diskstats.skip-idle is set, they are exposed from then on
+	// even if they become idle so their series don't have gaps.
+	skipIdle      bool
+	activeDevices map[string]bool
+}
+
+// diskLatencySample holds the completed I/Os of a device and the time spent
+// on them from the previous scrape.
+type diskLatencySample struct {
+	ops    float64
+	millis float64
 }
 
 func init() {
@@ -67,11 +97,21 @@ func init() {
 func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 	var diskLabelNames = []string{"device"}
 
+	var avgLatencyDesc *prometheus.Desc
+	if *diskAvgLatency {
+		avgLatencyDesc = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, diskSubsystem, "avg_latency_seconds"),
+			"The average time reads and writes completed since the previous scrape took.",
+			diskLabelNames,
+			nil,
+		)
+	}
+
 	return &diskstatsCollector{
 		ignoredDevicesPattern: regexp.MustCompile(*ignoredDevices),
 		descs: []typedFactorDesc{
 			{
-				desc: readsCompletedDesc, valueType: prometheus.CounterValue,
+				desc: readsCompletedDesc(), valueType: prometheus.CounterValue,
 			},
 			{
 				desc: prometheus.NewDesc(
@@ -82,15 +122,15 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 				), valueType: prometheus.CounterValue,
 			},
 			{
-				desc: readBytesDesc, valueType: prometheus.CounterValue,
+				desc: readBytesDesc(), valueType: prometheus.CounterValue,
 				factor: diskSectorSize,
 			},
 			{
-				desc: readTimeSecondsDesc, valueType: prometheus.CounterValue,
+				desc: readTimeSecondsDesc(), valueType: prometheus.CounterValue,
 				factor: .001,
 			},
 			{
-				desc: writesCompletedDesc, valueType: prometheus.CounterValue,
+				desc: writesCompletedDesc(), valueType: prometheus.CounterValue,
 			},
 			{
 				desc: prometheus.NewDesc(
@@ -101,11 +141,11 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 				), valueType: prometheus.CounterValue,
 			},
 			{
-				desc: writtenBytesDesc, valueType: prometheus.CounterValue,
+				desc: writtenBytesDesc(), valueType: prometheus.CounterValue,
 				factor: diskSectorSize,
 			},
 			{
-				desc: writeTimeSecondsDesc, valueType: prometheus.CounterValue,
+				desc: writeTimeSecondsDesc(), valueType: prometheus.CounterValue,
 				factor: .001,
 			},
 			{
@@ -117,7 +157,7 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 				), valueType: prometheus.GaugeValue,
 			},
 			{
-				desc: ioTimeSecondsDesc, valueType: prometheus.CounterValue,
+				desc: ioTimeSecondsDesc(), valueType: prometheus.CounterValue,
 				factor: .001,
 			},
 			{
@@ -180,7 +220,11 @@ func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 				factor: .001,
 			},
 		},
-		logger: logger,
+		logger:         logger,
+		avgLatencyDesc: avgLatencyDesc,
+		lastLatency:    map[string]diskLatencySample{},
+		skipIdle:       *diskSkipIdle,
+		activeDevices:  map[string]bool{},
 	}, nil
 }
 
@@ -195,6 +239,10 @@ func (c *diskstatsCollector) Update(ch chan<- prometheus.Metric) error {
 			level.Debug(c.logger).Log("msg", "Ignoring device", "device", dev)
 			continue
 		}
+		if c.skipIdle && !c.isActive(dev, stats) {
+			level.Debug(c.logger).Log("msg", "Skipping idle device", "device", dev)
+			continue
+		}
 
 		for i, value := range stats {
 			// ignore unrecognized additional stats
@@ -207,10 +255,88 @@ func (c *diskstatsCollector) Update(ch chan<- prometheus.Metric) error {
 			}
 			ch <- c.descs[i].mustNewConstMetric(v, dev)
 		}
+
+		if c.avgLatencyDesc != nil {
+			if err := c.updateAvgLatency(ch, dev, stats); err != nil {
+				return err
+			}
+		}
+	}
+	if c.avgLatencyDesc != nil {
+		c.pruneAvgLatency(diskStats)
 	}
 	return nil
 }
 
+// updateAvgLatency exposes the time spent per completed I/O since the
+// previous scrape. Nothing is exposed for a device on its first scrape or if
+// it completed no I/O in between.
+func (c *diskstatsCollector) updateAvgLatency(ch chan<- prometheus.Metric, dev string, stats []string) error {
+	if len(stats) <= diskWriteTimeMillis {
+		return nil
+	}
+	var sample diskLatencySample
+	for _, i := range []int{diskReadsCompleted, diskWritesCompleted} {
+		v, err := strconv.ParseFloat(stats[i], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %s in diskstats: %w", stats[i], err)
+		}
+		sample.ops += v
+	}
+	for _, i := range []int{diskReadTimeMillis, diskWriteTimeMillis} {
+		v, err := strconv.ParseFloat(stats[i], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %s in diskstats: %w", stats[i], err)
+		}
+		sample.millis += v
+	}
+
+	c.mtx.Lock()
+	last, ok := c.lastLatency[dev]
+	c.lastLatency[dev] = sample
+	c.mtx.Unlock()
+
+	ops := sample.ops - last.ops
+	// The counters of a device can be reset, e.g. when it is re-attached.
+	if !ok || ops <= 0 || sample.millis < last.millis {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(c.avgLatencyDesc, prometheus.GaugeValue,
+		(sample.millis-last.millis)/ops*.001, dev)
+	return nil
+}
+
+// pruneAvgLatency forgets the samples of devices which are gone, like
+// detached USB drives or removed device mapper volumes.
+func (c *diskstatsCollector) pruneAvgLatency(diskStats map[string][]string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for dev := range c.lastLatency {
+		if _, ok := diskStats[dev]; !ok {
+			delete(c.lastLatency, dev)
+		}
+	}
+}
+
+// isActive returns whether dev completed any reads or writes, now or in a
+// previous scrape.
+func (c *diskstatsCollector) isActive(dev string, stats []string) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.activeDevices[dev] {
+		return true
+	}
+	for _, i := range []int{diskReadsCompleted, diskWritesCompleted} {
+		if i < len(stats) && stats[i] != "0" {
+			c.activeDevices[dev] = true
+			return true
+		}
+	}
+	return false
+}
+
 func getDiskStats() (map[string][]string, error) {
 	file, err := os.Open(procFilePath(diskstatsFilename))
 	if err != nil {
@@ -16,6 +16,9 @@ package collector
 import (
 	"os"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestDiskStats(t *testing.T) {
@@ -50,3 +53,67 @@ func TestDiskStats(t *testing.T) {
 		t.Errorf("want diskstats sdc %s, got %s", want, got)
 	}
 }
+
+func TestDiskStatsAvgLatency(t *testing.T) {
+	c := diskstatsCollector{
+		avgLatencyDesc: prometheus.NewDesc("test_avg_latency_seconds", "Average latency.", []string{"device"}, nil),
+		lastLatency:    map[string]diskLatencySample{},
+	}
+	scrape := func(stats []string) []prometheus.Metric {
+		ch := make(chan prometheus.Metric, 1)
+		if err := c.updateAvgLatency(ch, "sda", stats); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		return metrics
+	}
+
+	if got := scrape([]string{"100", "0", "0", "500", "50", "0", "0", "250"}); len(got) != 0 {
+		t.Fatalf("want no latency on the first scrape, got %d metrics", len(got))
+	}
+
+	// 50 more I/Os took 1000ms.
+	got := scrape([]string{"130", "0", "0", "1100", "70", "0", "0", "650"})
+	if len(got) != 1 {
+		t.Fatalf("want one latency metric, got %d", len(got))
+	}
+	var m dto.Metric
+	if err := got[0].Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 0.02, m.GetGauge().GetValue(); want != got {
+		t.Errorf("want average latency %v, got %v", want, got)
+	}
+
+	if got := scrape([]string{"130", "0", "0", "1100", "70", "0", "0", "650"}); len(got) != 0 {
+		t.Errorf("want no latency without I/O, got %d metrics", len(got))
+	}
+
+	c.pruneAvgLatency(map[string][]string{"sda": nil})
+	if _, ok := c.lastLatency["sda"]; !ok {
+		t.Error("want the sample of sda to be kept while it exists")
+	}
+	c.pruneAvgLatency(map[string][]string{"sdb": nil})
+	if _, ok := c.lastLatency["sda"]; ok {
+		t.Error("want the sample of sda to be pruned once it is gone")
+	}
+}
+
+func TestDiskStatsIsActive(t *testing.T) {
+	c := diskstatsCollector{activeDevices: map[string]bool{}}
+
+	if c.isActive("loop0", []string{"0", "0", "0", "0", "0", "0", "0", "0"}) {
+		t.Error("want loop0 without I/O to be idle")
+	}
+	if !c.isActive("loop0", []string{"0", "0", "0", "0", "3", "0", "0", "10"}) {
+		t.Error("want loop0 with writes to be active")
+	}
+	// The counters of a device are reset when it is re-attached.
+	if !c.isActive("loop0", []string{"0", "0", "0", "0", "0", "0", "0", "0"}) {
+		t.Error("want loop0 to stay active after it was active once")
+	}
+}
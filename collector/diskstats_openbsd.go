@@ -45,11 +45,11 @@ func init() {
 // NewDiskstatsCollector returns a new Collector exposing disk device stats.
 func NewDiskstatsCollector(logger log.Logger) (Collector, error) {
 	return &diskstatsCollector{
-		rxfer:  typedDesc{readsCompletedDesc, prometheus.CounterValue},
-		rbytes: typedDesc{readBytesDesc, prometheus.CounterValue},
-		wxfer:  typedDesc{writesCompletedDesc, prometheus.CounterValue},
-		wbytes: typedDesc{writtenBytesDesc, prometheus.CounterValue},
-		time:   typedDesc{ioTimeSecondsDesc, prometheus.CounterValue},
+		rxfer:  typedDesc{readsCompletedDesc(), prometheus.CounterValue},
+		rbytes: typedDesc{readBytesDesc(), prometheus.CounterValue},
+		wxfer:  typedDesc{writesCompletedDesc(), prometheus.CounterValue},
+		wbytes: typedDesc{writtenBytesDesc(), prometheus.CounterValue},
+		time:   typedDesc{ioTimeSecondsDesc(), prometheus.CounterValue},
 		logger: logger,
 	}, nil
 }
@@ -0,0 +1,333 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodmcache
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const dmcacheSubsystem = "dmcache"
+
+// Constants from linux/dm-ioctl.h.
+const (
+	dmControlPath       = "/dev/mapper/control"
+	dmListDevicesCmd    = 3
+	dmTableStatusCmd    = 12
+	dmBufferFullFlag    = 1 << 8
+	dmNoFlushFlag       = 1 << 11
+	dmInitialBufferSize = 16 * 1024
+	dmMaxBufferSize     = 1024 * 1024
+	dmNameListNameStart = 12
+	dmSectorSize        = 512
+)
+
+// dmIoctl and dmTargetSpec are struct dm_ioctl and struct dm_target_spec from
+// linux/dm-ioctl.h. The data of a request follows the dm_ioctl header in the
+// same buffer.
+type dmIoctl struct {
+	version     [3]uint32
+	dataSize    uint32
+	dataStart   uint32
+	targetCount uint32
+	openCount   int32
+	flags       uint32
+	eventNr     uint32
+	padding     uint32
+	dev         uint64
+	name        [128]byte
+	uuid        [129]byte
+	data        [7]byte
+}
+
+type dmTargetSpec struct {
+	sectorStart uint64
+	length      uint64
+	status      int32
+	next        uint32
+	targetType  [16]byte
+}
+
+// dmIoctlNumber returns the _IOWR request number of a device-mapper command.
+func dmIoctlNumber(nr uintptr) uintptr {
+	return 3<<30 | unsafe.Sizeof(dmIoctl{})<<16 | 0xfd<<8 | nr
+}
+
+// dmTarget is a target of the table of a device-mapper device with its
+// status line.
+type dmTarget struct {
+	targetType string
+	status     string
+}
+
+// dmControl lists the device-mapper devices and returns the status of their
+// targets.
+type dmControl interface {
+	devices() ([]string, error)
+	tableStatus(name string) ([]dmTarget, error)
+	Close() error
+}
+
+// dmCacheStatus is the status line of a cache target as documented in
+// Documentation/admin-guide/device-mapper/cache.rst.
+type dmCacheStatus struct {
+	blockSize               float64
+	usedBlocks, totalBlocks float64
+	readHits, readMisses    float64
+	writeHits, writeMisses  float64
+	demotions, promotions   float64
+	dirtyBlocks             float64
+}
+
+type dmcacheCollector struct {
+	// open opens the device-mapper control device, it is replaced in tests.
+	open func() (dmControl, error)
+
+	blockSize, usedBlocks, blocks      typedDesc
+	readHits, readMisses               typedDesc
+	writeHits, writeMisses             typedDesc
+	demotions, promotions, dirtyBlocks typedDesc
+	logger                             log.Logger
+}
+
+func init() {
+	registerCollector(dmcacheSubsystem, defaultDisabled, NewDMCacheCollector)
+}
+
+// NewDMCacheCollector returns a new Collector exposing the statistics of
+// device-mapper cache targets, like the ones of LVM cache volumes.
+func NewDMCacheCollector(logger log.Logger) (Collector, error) {
+	desc := func(name, help string, valueType prometheus.ValueType) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, dmcacheSubsystem, name),
+			help, []string{"device"}, nil,
+		), valueType}
+	}
+	return &dmcacheCollector{
+		open:        openDMControl,
+		blockSize:   desc("block_size_bytes", "Size of a cache block.", prometheus.GaugeValue),
+		usedBlocks:  desc("used_blocks", "Number of used cache blocks.", prometheus.GaugeValue),
+		blocks:      desc("blocks", "Number of cache blocks.", prometheus.GaugeValue),
+		readHits:    desc("read_hits_total", "Number of reads served by the cache.", prometheus.CounterValue),
+		readMisses:  desc("read_misses_total", "Number of reads served by the origin device.", prometheus.CounterValue),
+		writeHits:   desc("write_hits_total", "Number of writes to blocks in the cache.", prometheus.CounterValue),
+		writeMisses: desc("write_misses_total", "Number of writes to blocks not in the cache.", prometheus.CounterValue),
+		demotions:   desc("demotions_total", "Number of blocks removed from the cache.", prometheus.CounterValue),
+		promotions:  desc("promotions_total", "Number of blocks moved into the cache.", prometheus.CounterValue),
+		dirtyBlocks: desc("dirty_blocks", "Number of cache blocks not written back to the origin device yet.", prometheus.GaugeValue),
+		logger:      logger,
+	}, nil
+}
+
+func (c *dmcacheCollector) Update(ch chan<- prometheus.Metric) error {
+	ctl, err := c.open()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			level.Debug(c.logger).Log("msg", "Couldn't open device-mapper control device, skipping", "err", err)
+			return ErrNoData
+		}
+		return fmt.Errorf("couldn't open device-mapper control device: %w", err)
+	}
+	defer ctl.Close()
+
+	devices, err := ctl.devices()
+	if err != nil {
+		return fmt.Errorf("couldn't list device-mapper devices: %w", err)
+	}
+	for _, device := range devices {
+		targets, err := ctl.tableStatus(device)
+		if err != nil {
+			// The device may have been removed since it was listed.
+			level.Debug(c.logger).Log("msg", "Couldn't get device-mapper table status", "device", device, "err", err)
+			continue
+		}
+		for _, t := range targets {
+			if t.targetType != "cache" {
+				continue
+			}
+			s, err := parseDMCacheStatus(t.status)
+			if err != nil {
+				level.Debug(c.logger).Log("msg", "Skipping cache target", "device", device, "err", err)
+				continue
+			}
+			ch <- c.blockSize.mustNewConstMetric(s.blockSize, device)
+			ch <- c.usedBlocks.mustNewConstMetric(s.usedBlocks, device)
+			ch <- c.blocks.mustNewConstMetric(s.totalBlocks, device)
+			ch <- c.readHits.mustNewConstMetric(s.readHits, device)
+			ch <- c.readMisses.mustNewConstMetric(s.readMisses, device)
+			ch <- c.writeHits.mustNewConstMetric(s.writeHits, device)
+			ch <- c.writeMisses.mustNewConstMetric(s.writeMisses, device)
+			ch <- c.demotions.mustNewConstMetric(s.demotions, device)
+			ch <- c.promotions.mustNewConstMetric(s.promotions, device)
+			ch <- c.dirtyBlocks.mustNewConstMetric(s.dirtyBlocks, device)
+			// LVM uses a single cache target per device, more would
+			// result in duplicate series.
+			break
+		}
+	}
+	return nil
+}
+
+// parseDMCacheStatus parses the status line of a cache target:
+//
+//	<metadata block size> <#used metadata blocks>/<#total metadata blocks>
+//	<cache block size> <#used cache blocks>/<#total cache blocks>
+//	<#read hits> <#read misses> <#write hits> <#write misses>
+//	<#demotions> <#promotions> <#dirty> ...
+func parseDMCacheStatus(status string) (dmCacheStatus, error) {
+	var s dmCacheStatus
+	fields := strings.Fields(status)
+	if len(fields) < 11 {
+		return s, fmt.Errorf("invalid cache status %q", status)
+	}
+	blocks := strings.Split(fields[3], "/")
+	if len(blocks) != 2 {
+		return s, fmt.Errorf("invalid cache blocks %q", fields[3])
+	}
+	for _, v := range []struct {
+		dst *float64
+		str string
+	}{
+		{&s.blockSize, fields[2]},
+		{&s.usedBlocks, blocks[0]},
+		{&s.totalBlocks, blocks[1]},
+		{&s.readHits, fields[4]},
+		{&s.readMisses, fields[5]},
+		{&s.writeHits, fields[6]},
+		{&s.writeMisses, fields[7]},
+		{&s.demotions, fields[8]},
+		{&s.promotions, fields[9]},
+		{&s.dirtyBlocks, fields[10]},
+	} {
+		value, err := strconv.ParseUint(v.str, 10, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid value %q in cache status: %w", v.str, err)
+		}
+		*v.dst = float64(value)
+	}
+	// The cache block size is given in sectors.
+	s.blockSize *= dmSectorSize
+	return s, nil
+}
+
+// dmDevice is the device-mapper control device.
+type dmDevice struct {
+	f *os.File
+}
+
+func openDMControl() (dmControl, error) {
+	f, err := os.Open(rootfsFilePath(dmControlPath))
+	if err != nil {
+		return nil, err
+	}
+	return &dmDevice{f: f}, nil
+}
+
+func (d *dmDevice) Close() error {
+	return d.f.Close()
+}
+
+// ioctl runs a device-mapper command with flags for the device name and
+// returns the result data. The buffer is grown until the result fits.
+func (d *dmDevice) ioctl(cmd uintptr, flags uint32, name string) ([]byte, error) {
+	headerSize := unsafe.Sizeof(dmIoctl{})
+	for size := dmInitialBufferSize; ; size *= 2 {
+		// Back the buffer with uint64s to align the header.
+		words := make([]uint64, size/8)
+		buf := (*[dmMaxBufferSize]byte)(unsafe.Pointer(&words[0]))[:size:size]
+		hdr := (*dmIoctl)(unsafe.Pointer(&words[0]))
+		hdr.version = [3]uint32{4, 0, 0}
+		hdr.dataSize = uint32(size)
+		hdr.dataStart = uint32(headerSize)
+		hdr.flags = flags
+		copy(hdr.name[:len(hdr.name)-1], name)
+
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), dmIoctlNumber(cmd), uintptr(unsafe.Pointer(&words[0])))
+		if errno != 0 {
+			return nil, errno
+		}
+		if hdr.flags&dmBufferFullFlag == 0 {
+			if hdr.dataStart > hdr.dataSize || int(hdr.dataSize) > size {
+				return nil, errors.New("invalid device-mapper result size")
+			}
+			return buf[hdr.dataStart:hdr.dataSize], nil
+		}
+		if size == dmMaxBufferSize {
+			return nil, errors.New("device-mapper result too large")
+		}
+	}
+}
+
+func (d *dmDevice) devices() ([]string, error) {
+	data, err := d.ioctl(dmListDevicesCmd, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	// The result is a list of struct dm_name_list, each with the offset of
+	// the next one, an empty list has a single entry with dev 0.
+	var names []string
+	for offset := 0; offset+dmNameListNameStart <= len(data); {
+		entry := data[offset:]
+		dev := *(*uint64)(unsafe.Pointer(&entry[0]))
+		next := *(*uint32)(unsafe.Pointer(&entry[8]))
+		if dev == 0 {
+			break
+		}
+		names = append(names, bytesToString(entry[dmNameListNameStart:]))
+		if next == 0 {
+			break
+		}
+		offset += int(next)
+	}
+	return names, nil
+}
+
+func (d *dmDevice) tableStatus(name string) ([]dmTarget, error) {
+	// Without DM_NOFLUSH_FLAG, cache and thin pool targets commit their
+	// metadata before reporting their status.
+	data, err := d.ioctl(dmTableStatusCmd, dmNoFlushFlag, name)
+	if err != nil {
+		return nil, err
+	}
+	// The result is a struct dm_target_spec followed by the status line for
+	// each target, with the offset of the next one from the start of the
+	// data.
+	var (
+		targets  []dmTarget
+		specSize = int(unsafe.Sizeof(dmTargetSpec{}))
+	)
+	for offset := 0; offset+specSize <= len(data); {
+		spec := (*dmTargetSpec)(unsafe.Pointer(&data[offset]))
+		targets = append(targets, dmTarget{
+			targetType: bytesToString(spec.targetType[:]),
+			status:     bytesToString(data[offset+specSize:]),
+		})
+		if int(spec.next) <= offset {
+			break
+		}
+		offset = int(spec.next)
+	}
+	return targets, nil
+}
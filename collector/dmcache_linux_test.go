@@ -0,0 +1,117 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodmcache
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sys/unix"
+)
+
+type fakeDMControl map[string][]dmTarget
+
+func (f fakeDMControl) Close() error { return nil }
+
+func (f fakeDMControl) devices() ([]string, error) {
+	return []string{"vg-cached", "vg-linear", "vg-removed"}, nil
+}
+
+func (f fakeDMControl) tableStatus(name string) ([]dmTarget, error) {
+	targets, ok := f[name]
+	if !ok {
+		return nil, unix.ENXIO
+	}
+	return targets, nil
+}
+
+func TestDMCacheCollector(t *testing.T) {
+	c, err := NewDMCacheCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*dmcacheCollector).open = func() (dmControl, error) {
+		return fakeDMControl{
+			"vg-cached": {{
+				targetType: "cache",
+				status:     "8 1088/8192 128 23936/81920 1234 567 890 12 3 45 67 1 writeback 2 migration_threshold 2048 smq 0 rw -",
+			}},
+			"vg-linear": {{targetType: "linear"}},
+		}, nil
+	}
+
+	expected := `
+# HELP node_dmcache_block_size_bytes Size of a cache block.
+# TYPE node_dmcache_block_size_bytes gauge
+node_dmcache_block_size_bytes{device="vg-cached"} 65536
+# HELP node_dmcache_blocks Number of cache blocks.
+# TYPE node_dmcache_blocks gauge
+node_dmcache_blocks{device="vg-cached"} 81920
+# HELP node_dmcache_demotions_total Number of blocks removed from the cache.
+# TYPE node_dmcache_demotions_total counter
+node_dmcache_demotions_total{device="vg-cached"} 3
+# HELP node_dmcache_dirty_blocks Number of cache blocks not written back to the origin device yet.
+# TYPE node_dmcache_dirty_blocks gauge
+node_dmcache_dirty_blocks{device="vg-cached"} 67
+# HELP node_dmcache_promotions_total Number of blocks moved into the cache.
+# TYPE node_dmcache_promotions_total counter
+node_dmcache_promotions_total{device="vg-cached"} 45
+# HELP node_dmcache_read_hits_total Number of reads served by the cache.
+# TYPE node_dmcache_read_hits_total counter
+node_dmcache_read_hits_total{device="vg-cached"} 1234
+# HELP node_dmcache_read_misses_total Number of reads served by the origin device.
+# TYPE node_dmcache_read_misses_total counter
+node_dmcache_read_misses_total{device="vg-cached"} 567
+# HELP node_dmcache_used_blocks Number of used cache blocks.
+# TYPE node_dmcache_used_blocks gauge
+node_dmcache_used_blocks{device="vg-cached"} 23936
+# HELP node_dmcache_write_hits_total Number of writes to blocks in the cache.
+# TYPE node_dmcache_write_hits_total counter
+node_dmcache_write_hits_total{device="vg-cached"} 890
+# HELP node_dmcache_write_misses_total Number of writes to blocks not in the cache.
+# TYPE node_dmcache_write_misses_total counter
+node_dmcache_write_misses_total{device="vg-cached"} 12
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseDMCacheStatusFail(t *testing.T) {
+	if _, err := parseDMCacheStatus("Fail"); err == nil {
+		t.Error("want error for failed cache target")
+	}
+}
+
+func TestDMCacheCollectorNoDevice(t *testing.T) {
+	c, err := NewDMCacheCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*dmcacheCollector).open = func() (dmControl, error) { return nil, os.ErrPermission }
+	if err := c.Update(nil); err != ErrNoData {
+		t.Errorf("want ErrNoData, got %v", err)
+	}
+}
+
+func TestDMIoctlNumber(t *testing.T) {
+	if got := dmIoctlNumber(dmTableStatusCmd); got != 0xc138fd0c {
+		t.Errorf("want DM_TABLE_STATUS 0xc138fd0c, got %#x", got)
+	}
+}
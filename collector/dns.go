@@ -0,0 +1,131 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodns
+
+package collector
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const dnsSubsystem = "dns"
+
+var (
+	dnsTargets = kingpin.Flag("collector.dns.targets", "Hostname to resolve for the dns collector, can be repeated.").Strings()
+	dnsTimeout = kingpin.Flag("collector.dns.timeout", "Timeout for resolving a single hostname.").Default("2s").Duration()
+)
+
+type dnsCollector struct {
+	targets []string
+	timeout time.Duration
+	// lookup resolves host, it is replaced in tests.
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	duration typedDesc
+	success  typedDesc
+	logger   log.Logger
+}
+
+type dnsLookupResult struct {
+	host     string
+	duration time.Duration
+	err      error
+}
+
+func init() {
+	registerCollector(dnsSubsystem, defaultDisabled, NewDNSCollector)
+}
+
+// NewDNSCollector returns a new Collector measuring how long the host needs
+// to resolve the configured hostnames.
+func NewDNSCollector(logger log.Logger) (Collector, error) {
+	// Repeated hosts would be exposed as duplicate series.
+	targets := make([]string, 0, len(*dnsTargets))
+	seen := map[string]bool{}
+	for _, host := range *dnsTargets {
+		if !seen[host] {
+			seen[host] = true
+			targets = append(targets, host)
+		}
+	}
+
+	return &dnsCollector{
+		targets: targets,
+		timeout: *dnsTimeout,
+		lookup:  net.DefaultResolver.LookupHost,
+		duration: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, dnsSubsystem, "lookup_duration_seconds"),
+			"Time taken to resolve the host.",
+			[]string{"host"}, nil,
+		), prometheus.GaugeValue},
+		success: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, dnsSubsystem, "lookup_success"),
+			"Whether the host could be resolved.",
+			[]string{"host"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *dnsCollector) Update(ch chan<- prometheus.Metric) error {
+	return c.UpdateContext(context.Background(), ch)
+}
+
+// UpdateContext implements ContextCollector. The targets are resolved in
+// parallel, each bounded by the lookup timeout and the scrape.
+func (c *dnsCollector) UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if len(c.targets) == 0 {
+		level.Debug(c.logger).Log("msg", "No --collector.dns.targets configured, skipping")
+		return ErrNoData
+	}
+
+	results := make([]dnsLookupResult, len(c.targets))
+	var wg sync.WaitGroup
+	for i, host := range c.targets {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			results[i] = c.resolve(ctx, host)
+		}(i, host)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		success := 1.0
+		if r.err != nil {
+			level.Debug(c.logger).Log("msg", "Failed to resolve host", "host", r.host, "err", r.err)
+			success = 0
+		}
+		ch <- c.duration.mustNewConstMetric(r.duration.Seconds(), r.host)
+		ch <- c.success.mustNewConstMetric(success, r.host)
+	}
+	return nil
+}
+
+func (c *dnsCollector) resolve(ctx context.Context, host string) dnsLookupResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	begin := time.Now()
+	_, err := c.lookup(ctx, host)
+	return dnsLookupResult{host: host, duration: time.Since(begin), err: err}
+}
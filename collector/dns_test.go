@@ -0,0 +1,79 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodns
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestDNSCollector(t *testing.T) {
+	c, err := NewDNSCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := c.(*dnsCollector)
+	dc.targets = []string{"example.com", "nxdomain.invalid", "stuck.example.com"}
+	dc.timeout = 10 * time.Millisecond
+	dc.lookup = func(ctx context.Context, host string) ([]string, error) {
+		switch host {
+		case "example.com":
+			return []string{"192.0.2.1"}, nil
+		case "stuck.example.com":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("no such host")
+	}
+
+	want := `# HELP node_dns_lookup_success Whether the host could be resolved.
+# TYPE node_dns_lookup_success gauge
+node_dns_lookup_success{host="example.com"} 1
+node_dns_lookup_success{host="nxdomain.invalid"} 0
+node_dns_lookup_success{host="stuck.example.com"} 0
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want), "node_dns_lookup_success"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDNSCollectorDuplicateTargets(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{
+		"--collector.dns.targets", "example.com",
+		"--collector.dns.targets", "example.org",
+		"--collector.dns.targets", "example.com",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	c, err := NewDNSCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"example.com", "example.org"}
+	if got := c.(*dnsCollector).targets; !reflect.DeepEqual(got, want) {
+		t.Errorf("want targets %v, got %v", want, got)
+	}
+}
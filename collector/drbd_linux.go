@@ -205,6 +205,16 @@ func (c *drbdCollector) Update(ch chan<- prometheus.Metric) error {
 	for scanner.Scan() {
 		field := scanner.Text()
 
+		if field == "version:" && scanner.Scan() {
+			// DRBD 9 no longer reports per device statistics in /proc/drbd.
+			version := scanner.Text()
+			if major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0]); err == nil && major >= 9 {
+				level.Debug(c.logger).Log("msg", "DRBD 9 and later don't expose statistics in /proc/drbd, skipping", "version", version)
+				return ErrNoData
+			}
+			continue
+		}
+
 		kv := strings.Split(field, ":")
 		if len(kv) != 2 {
 			level.Debug(c.logger).Log("msg", "skipping invalid key:value pair", "field", field)
@@ -24,6 +24,7 @@ import (
 
 type entropyCollector struct {
 	entropyAvail *prometheus.Desc
+	poolSize     *prometheus.Desc
 	logger       log.Logger
 }
 
@@ -36,7 +37,12 @@ func NewEntropyCollector(logger log.Logger) (Collector, error) {
 	return &entropyCollector{
 		entropyAvail: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "entropy_available_bits"),
-			"Bits of available entropy.",
+			"Bits of available entropy. Since Linux 5.18 this is always 256.",
+			nil, nil,
+		),
+		poolSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "entropy_pool_size_bits"),
+			"Bits of entropy pool.",
 			nil, nil,
 		),
 		logger: logger,
@@ -51,5 +57,12 @@ func (c *entropyCollector) Update(ch chan<- prometheus.Metric) error {
 	ch <- prometheus.MustNewConstMetric(
 		c.entropyAvail, prometheus.GaugeValue, float64(value))
 
+	value, err = readUintFromFile(procFilePath("sys/kernel/random/poolsize"))
+	if err != nil {
+		return fmt.Errorf("couldn't get entropy poolsize: %w", err)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.poolSize, prometheus.GaugeValue, float64(value))
+
 	return nil
 }
@@ -0,0 +1,498 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noethtool
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"runtime"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	ethtoolDeviceInclude  = kingpin.Flag("collector.ethtool.device-include", "Regexp of ethtool devices to include (mutually exclusive to device-exclude).").String()
+	ethtoolDeviceExclude  = kingpin.Flag("collector.ethtool.device-exclude", "Regexp of ethtool devices to exclude (mutually exclusive to device-include).").String()
+	ethtoolMetricsInclude = kingpin.Flag("collector.ethtool.metrics-include", "Regexp of ethtool stats to include.").Default(".*").String()
+)
+
+// Constants from linux/sockios.h and linux/ethtool.h.
+const (
+	siocEthtool = 0x8946
+
+	ethtoolGDrvInfo      = 0x03
+	ethtoolGRingParam    = 0x10
+	ethtoolGStrings      = 0x1b
+	ethtoolGStats        = 0x1d
+	ethtoolGSSetInfo     = 0x37
+	ethtoolGChannels     = 0x3c
+	ethtoolGLinkSettings = 0x4c
+
+	ethSSStats        = 1
+	ethGStringLen     = 32
+	ethtoolSpeedNone  = 0xffffffff
+	ethtoolDuplexFull = 0x01
+)
+
+type ethtoolDriverInfo struct {
+	driver          string
+	version         string
+	firmwareVersion string
+	busInfo         string
+}
+
+type ethtoolLinkSettings struct {
+	// speed is in Mb/s, 0 if unknown.
+	speed   uint32
+	duplex  uint8
+	autoneg uint8
+}
+
+// ethtoolRingParams is struct ethtool_ringparam from linux/ethtool.h, with
+// the sizes of the rx and tx rings of a device.
+type ethtoolRingParams struct {
+	cmd               uint32
+	rxMaxPending      uint32
+	rxMiniMaxPending  uint32
+	rxJumboMaxPending uint32
+	txMaxPending      uint32
+	rxPending         uint32
+	rxMiniPending     uint32
+	rxJumboPending    uint32
+	txPending         uint32
+}
+
+// ethtoolChannels is struct ethtool_channels from linux/ethtool.h, with the
+// number of queues of a device.
+type ethtoolChannels struct {
+	cmd           uint32
+	maxRx         uint32
+	maxTx         uint32
+	maxOther      uint32
+	maxCombined   uint32
+	rxCount       uint32
+	txCount       uint32
+	otherCount    uint32
+	combinedCount uint32
+}
+
+// ethtoolStats gets information about a network device, usually by calling
+// the SIOCETHTOOL ioctl.
+type ethtoolStats interface {
+	DriverInfo(device string) (ethtoolDriverInfo, error)
+	Stats(device string) (map[string]uint64, error)
+	LinkSettings(device string) (ethtoolLinkSettings, error)
+	RingParams(device string) (ethtoolRingParams, error)
+	Channels(device string) (ethtoolChannels, error)
+	Close() error
+}
+
+type ethtoolCollector struct {
+	// open returns the ethtoolStats of a scrape, it is closed afterwards.
+	open                 func() (ethtoolStats, error)
+	deviceIncludePattern *regexp.Regexp
+	deviceExcludePattern *regexp.Regexp
+	metricsPattern       *regexp.Regexp
+	infoDesc             *prometheus.Desc
+	statsDesc            *prometheus.Desc
+	speedDesc            *prometheus.Desc
+	duplexDesc           *prometheus.Desc
+	autonegDesc          *prometheus.Desc
+	rxRingSizeDesc       *prometheus.Desc
+	rxRingMaxDesc        *prometheus.Desc
+	txRingSizeDesc       *prometheus.Desc
+	txRingMaxDesc        *prometheus.Desc
+	combinedChannelsDesc *prometheus.Desc
+	logger               log.Logger
+}
+
+func init() {
+	registerCollector("ethtool", defaultDisabled, NewEthtoolCollector)
+}
+
+// NewEthtoolCollector returns a new Collector exposing ethtool stats.
+func NewEthtoolCollector(logger log.Logger) (Collector, error) {
+	if *ethtoolDeviceExclude != "" && *ethtoolDeviceInclude != "" {
+		return nil, errors.New("device-exclude & device-include are mutually exclusive")
+	}
+
+	var excludePattern *regexp.Regexp
+	if *ethtoolDeviceExclude != "" {
+		level.Info(logger).Log("msg", "Parsed flag --collector.ethtool.device-exclude", "flag", *ethtoolDeviceExclude)
+		excludePattern = regexp.MustCompile(*ethtoolDeviceExclude)
+	}
+
+	var includePattern *regexp.Regexp
+	if *ethtoolDeviceInclude != "" {
+		level.Info(logger).Log("msg", "Parsed flag --collector.ethtool.device-include", "flag", *ethtoolDeviceInclude)
+		includePattern = regexp.MustCompile(*ethtoolDeviceInclude)
+	}
+
+	metricsPattern, err := regexp.Compile(*ethtoolMetricsInclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --collector.ethtool.metrics-include: %w", err)
+	}
+
+	return newEthtoolCollector(openEthtoolSocket, includePattern, excludePattern, metricsPattern, logger), nil
+}
+
+func newEthtoolCollector(open func() (ethtoolStats, error), include, exclude, metrics *regexp.Regexp, logger log.Logger) *ethtoolCollector {
+	const subsystem = "ethtool"
+
+	return &ethtoolCollector{
+		open:                 open,
+		deviceIncludePattern: include,
+		deviceExcludePattern: exclude,
+		metricsPattern:       metrics,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"A metric with a constant '1' value labeled by the driver information of the device.",
+			[]string{"device", "driver", "version", "firmware_version", "bus_info"}, nil,
+		),
+		statsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "statistics"),
+			"Driver specific statistic of the device.",
+			[]string{"device", "type"}, nil,
+		),
+		speedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "link_speed_bytes"),
+			"Link speed of the device in bytes per second.",
+			[]string{"device"}, nil,
+		),
+		duplexDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "link_duplex_full"),
+			"Whether the link of the device is full duplex.",
+			[]string{"device"}, nil,
+		),
+		autonegDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "link_autonegotiation"),
+			"Whether autonegotiation is enabled for the link of the device.",
+			[]string{"device"}, nil,
+		),
+		// The ring and queue sizes are configuration of the device like
+		// the sysfs attributes of the netclass collector.
+		rxRingSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "rx_ring_size"),
+			"Number of descriptors of the rx ring of the device.",
+			[]string{"device"}, nil,
+		),
+		rxRingMaxDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "rx_ring_max"),
+			"Maximum number of descriptors of the rx ring of the device.",
+			[]string{"device"}, nil,
+		),
+		txRingSizeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "tx_ring_size"),
+			"Number of descriptors of the tx ring of the device.",
+			[]string{"device"}, nil,
+		),
+		txRingMaxDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "tx_ring_max"),
+			"Maximum number of descriptors of the tx ring of the device.",
+			[]string{"device"}, nil,
+		),
+		combinedChannelsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "combined_channels"),
+			"Number of combined rx and tx queues of the device.",
+			[]string{"device"}, nil,
+		),
+		logger: logger,
+	}
+}
+
+func (c *ethtoolCollector) Update(ch chan<- prometheus.Metric) error {
+	devices, err := ioutil.ReadDir(sysFilePath("class/net"))
+	if err != nil {
+		return fmt.Errorf("couldn't get network devices: %w", err)
+	}
+
+	ethtool, err := c.open()
+	if err != nil {
+		return fmt.Errorf("failed to open socket for ethtool: %w", err)
+	}
+	defer ethtool.Close()
+
+	for _, d := range devices {
+		device := d.Name()
+		if c.deviceExcludePattern != nil && c.deviceExcludePattern.MatchString(device) {
+			continue
+		}
+		if c.deviceIncludePattern != nil && !c.deviceIncludePattern.MatchString(device) {
+			continue
+		}
+
+		// Devices that don't support an ioctl, like the loopback device or
+		// bonding_masters in /sys/class/net, are skipped.
+		info, err := ethtool.DriverInfo(device)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "couldn't get ethtool driver info", "device", device, "err", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1,
+			device, info.driver, info.version, info.firmwareVersion, info.busInfo)
+
+		stats, err := ethtool.Stats(device)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "couldn't get ethtool stats", "device", device, "err", err)
+		}
+		for name, value := range stats {
+			if !c.metricsPattern.MatchString(name) {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.statsDesc, prometheus.UntypedValue, float64(value), device, name)
+		}
+
+		if rings, err := ethtool.RingParams(device); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.rxRingSizeDesc, prometheus.GaugeValue, float64(rings.rxPending), device)
+			ch <- prometheus.MustNewConstMetric(c.rxRingMaxDesc, prometheus.GaugeValue, float64(rings.rxMaxPending), device)
+			ch <- prometheus.MustNewConstMetric(c.txRingSizeDesc, prometheus.GaugeValue, float64(rings.txPending), device)
+			ch <- prometheus.MustNewConstMetric(c.txRingMaxDesc, prometheus.GaugeValue, float64(rings.txMaxPending), device)
+		} else {
+			level.Debug(c.logger).Log("msg", "couldn't get ethtool ring parameters", "device", device, "err", err)
+		}
+
+		if channels, err := ethtool.Channels(device); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.combinedChannelsDesc, prometheus.GaugeValue, float64(channels.combinedCount), device)
+		} else {
+			level.Debug(c.logger).Log("msg", "couldn't get ethtool channels", "device", device, "err", err)
+		}
+
+		settings, err := ethtool.LinkSettings(device)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "couldn't get ethtool link settings", "device", device, "err", err)
+			continue
+		}
+		if settings.speed != 0 {
+			ch <- prometheus.MustNewConstMetric(c.speedDesc, prometheus.GaugeValue, float64(settings.speed)*1000*1000/8, device)
+		}
+		var duplex float64
+		if settings.duplex == ethtoolDuplexFull {
+			duplex = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.duplexDesc, prometheus.GaugeValue, duplex, device)
+		ch <- prometheus.MustNewConstMetric(c.autonegDesc, prometheus.GaugeValue, float64(settings.autoneg), device)
+	}
+	return nil
+}
+
+// ethtoolSocket implements ethtoolStats with the SIOCETHTOOL ioctl on an
+// AF_INET socket.
+type ethtoolSocket struct {
+	fd int
+}
+
+func openEthtoolSocket() (ethtoolStats, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ethtoolSocket{fd: fd}, nil
+}
+
+func (s *ethtoolSocket) Close() error {
+	return unix.Close(s.fd)
+}
+
+// ifreq is struct ifreq with ifr_data from linux/if.h. The union is padded
+// to its full size since the kernel copies the whole struct.
+type ifreq struct {
+	name [unix.IFNAMSIZ]byte
+	data uintptr
+	_    [24 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+type ethtoolDrvinfo struct {
+	cmd         uint32
+	driver      [32]byte
+	version     [32]byte
+	fwVersion   [32]byte
+	busInfo     [32]byte
+	eromVersion [32]byte
+	reserved2   [12]byte
+	nPrivFlags  uint32
+	nStats      uint32
+	testinfoLen uint32
+	eedumpLen   uint32
+	regdumpLen  uint32
+}
+
+// ethtoolLinkSettingsHeader is the fixed part of struct
+// ethtool_link_settings, it is followed by three link mode masks of
+// linkModeMasksNwords 32 bit words each.
+type ethtoolLinkSettingsHeader struct {
+	cmd                 uint32
+	speed               uint32
+	duplex              uint8
+	port                uint8
+	phyAddress          uint8
+	autoneg             uint8
+	mdioSupport         uint8
+	ethTpMdix           uint8
+	ethTpMdixCtrl       uint8
+	linkModeMasksNwords int8
+	transceiver         uint8
+	masterSlaveCfg      uint8
+	masterSlaveState    uint8
+	reserved1           [1]uint8
+	reserved            [7]uint32
+}
+
+func (s *ethtoolSocket) ioctl(device string, data unsafe.Pointer) error {
+	var ifr ifreq
+	if len(device) >= len(ifr.name) {
+		return fmt.Errorf("device name too long: %q", device)
+	}
+	copy(ifr.name[:], device)
+	ifr.data = uintptr(data)
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(s.fd), siocEthtool, uintptr(unsafe.Pointer(&ifr)))
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (s *ethtoolSocket) drvinfo(device string) (*ethtoolDrvinfo, error) {
+	info := &ethtoolDrvinfo{cmd: ethtoolGDrvInfo}
+	if err := s.ioctl(device, unsafe.Pointer(info)); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *ethtoolSocket) DriverInfo(device string) (ethtoolDriverInfo, error) {
+	info, err := s.drvinfo(device)
+	if err != nil {
+		return ethtoolDriverInfo{}, err
+	}
+	return ethtoolDriverInfo{
+		driver:          bytesToString(info.driver[:]),
+		version:         bytesToString(info.version[:]),
+		firmwareVersion: bytesToString(info.fwVersion[:]),
+		busInfo:         bytesToString(info.busInfo[:]),
+	}, nil
+}
+
+// statsCount returns the number of ETH_SS_STATS strings using
+// ETHTOOL_GSSET_INFO.
+func (s *ethtoolSocket) statsCount(device string) (int, error) {
+	// struct ethtool_sset_info is two 32 bit words and a 64 bit mask
+	// followed by one 32 bit count per set in the mask.
+	var info [3]uint64
+	*(*uint32)(unsafe.Pointer(&info[0])) = ethtoolGSSetInfo
+	info[1] = 1 << ethSSStats
+	if err := s.ioctl(device, unsafe.Pointer(&info[0])); err != nil {
+		return 0, err
+	}
+	if info[1]&(1<<ethSSStats) == 0 {
+		return 0, nil
+	}
+	return int(*(*uint32)(unsafe.Pointer(&info[2]))), nil
+}
+
+func (s *ethtoolSocket) Stats(device string) (map[string]uint64, error) {
+	n, err := s.statsCount(device)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	// struct ethtool_gstrings is three 32 bit words followed by the strings.
+	names := make([]byte, 12+n*ethGStringLen)
+	*(*uint32)(unsafe.Pointer(&names[0])) = ethtoolGStrings
+	*(*uint32)(unsafe.Pointer(&names[4])) = ethSSStats
+	*(*uint32)(unsafe.Pointer(&names[8])) = uint32(n)
+	if err := s.ioctl(device, unsafe.Pointer(&names[0])); err != nil {
+		return nil, err
+	}
+	l := int(*(*uint32)(unsafe.Pointer(&names[8])))
+	if l > n {
+		return nil, fmt.Errorf("ethtool returned %d stat names, expected at most %d", l, n)
+	}
+	n = l
+
+	// struct ethtool_stats is two 32 bit words followed by 64 bit values.
+	values := make([]uint64, 1+n)
+	*(*uint32)(unsafe.Pointer(&values[0])) = ethtoolGStats
+	*(*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(&values[0])) + 4)) = uint32(n)
+	if err := s.ioctl(device, unsafe.Pointer(&values[0])); err != nil {
+		return nil, err
+	}
+	if l := int(*(*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(&values[0])) + 4))); l != n {
+		return nil, fmt.Errorf("ethtool returned %d stat values, expected %d", l, n)
+	}
+
+	stats := make(map[string]uint64, n)
+	for i := 0; i < n; i++ {
+		name := bytesToString(names[12+i*ethGStringLen : 12+(i+1)*ethGStringLen])
+		stats[name] = values[1+i]
+	}
+	return stats, nil
+}
+
+func (s *ethtoolSocket) RingParams(device string) (ethtoolRingParams, error) {
+	rings := ethtoolRingParams{cmd: ethtoolGRingParam}
+	err := s.ioctl(device, unsafe.Pointer(&rings))
+	return rings, err
+}
+
+func (s *ethtoolSocket) Channels(device string) (ethtoolChannels, error) {
+	channels := ethtoolChannels{cmd: ethtoolGChannels}
+	err := s.ioctl(device, unsafe.Pointer(&channels))
+	return channels, err
+}
+
+func (s *ethtoolSocket) LinkSettings(device string) (ethtoolLinkSettings, error) {
+	// The first call only returns the negated number of words of the link
+	// mode masks, which the second call needs to get the settings.
+	header := ethtoolLinkSettingsHeader{cmd: ethtoolGLinkSettings}
+	if err := s.ioctl(device, unsafe.Pointer(&header)); err != nil {
+		return ethtoolLinkSettings{}, err
+	}
+	nwords := -int(header.linkModeMasksNwords)
+	if nwords <= 0 {
+		return ethtoolLinkSettings{}, errors.New("ethtool link settings handshake failed")
+	}
+
+	headerSize := int(unsafe.Sizeof(header))
+	buf := make([]uint32, headerSize/4+3*nwords)
+	h := (*ethtoolLinkSettingsHeader)(unsafe.Pointer(&buf[0]))
+	h.cmd = ethtoolGLinkSettings
+	h.linkModeMasksNwords = int8(nwords)
+	if err := s.ioctl(device, unsafe.Pointer(&buf[0])); err != nil {
+		return ethtoolLinkSettings{}, err
+	}
+
+	settings := ethtoolLinkSettings{
+		speed:   h.speed,
+		duplex:  h.duplex,
+		autoneg: h.autoneg,
+	}
+	if settings.speed == ethtoolSpeedNone {
+		settings.speed = 0
+	}
+	return settings, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noethtool
+
+package collector
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sys/unix"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// fakeEthtool only supports eth0, like a host where the other devices are
+// virtual.
+type fakeEthtool struct{}
+
+func (fakeEthtool) DriverInfo(device string) (ethtoolDriverInfo, error) {
+	if device != "eth0" {
+		return ethtoolDriverInfo{}, unix.EOPNOTSUPP
+	}
+	return ethtoolDriverInfo{
+		driver:          "e1000e",
+		version:         "3.2.6-k",
+		firmwareVersion: "0.13-4",
+		busInfo:         "0000:00:19.0",
+	}, nil
+}
+
+func (fakeEthtool) Stats(device string) (map[string]uint64, error) {
+	return map[string]uint64{
+		"rx_crc_errors": 3,
+		"tx_dropped":    5,
+		"rx_packets":    1024,
+	}, nil
+}
+
+func (fakeEthtool) LinkSettings(device string) (ethtoolLinkSettings, error) {
+	return ethtoolLinkSettings{speed: 1000, duplex: ethtoolDuplexFull, autoneg: 1}, nil
+}
+
+func (fakeEthtool) RingParams(device string) (ethtoolRingParams, error) {
+	return ethtoolRingParams{rxMaxPending: 4096, rxPending: 1024, txMaxPending: 4096, txPending: 512}, nil
+}
+
+// Channels fails like for a device with a single queue.
+func (fakeEthtool) Channels(device string) (ethtoolChannels, error) {
+	return ethtoolChannels{}, unix.EOPNOTSUPP
+}
+
+func (fakeEthtool) Close() error { return nil }
+
+// closeCountingEthtool counts how often it was closed.
+type closeCountingEthtool struct {
+	fakeEthtool
+	closed *int
+}
+
+func (e closeCountingEthtool) Close() error {
+	*e.closed++
+	return nil
+}
+
+func TestEthtoolCollector(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.sysfs", "fixtures/sys"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP node_ethtool_info A metric with a constant '1' value labeled by the driver information of the device.
+# TYPE node_ethtool_info gauge
+node_ethtool_info{bus_info="0000:00:19.0",device="eth0",driver="e1000e",firmware_version="0.13-4",version="3.2.6-k"} 1
+# HELP node_ethtool_link_autonegotiation Whether autonegotiation is enabled for the link of the device.
+# TYPE node_ethtool_link_autonegotiation gauge
+node_ethtool_link_autonegotiation{device="eth0"} 1
+# HELP node_ethtool_link_duplex_full Whether the link of the device is full duplex.
+# TYPE node_ethtool_link_duplex_full gauge
+node_ethtool_link_duplex_full{device="eth0"} 1
+# HELP node_ethtool_link_speed_bytes Link speed of the device in bytes per second.
+# TYPE node_ethtool_link_speed_bytes gauge
+node_ethtool_link_speed_bytes{device="eth0"} 1.25e+08
+# HELP node_ethtool_statistics Driver specific statistic of the device.
+# TYPE node_ethtool_statistics untyped
+node_ethtool_statistics{device="eth0",type="rx_crc_errors"} 3
+node_ethtool_statistics{device="eth0",type="tx_dropped"} 5
+# HELP node_network_rx_ring_max Maximum number of descriptors of the rx ring of the device.
+# TYPE node_network_rx_ring_max gauge
+node_network_rx_ring_max{device="eth0"} 4096
+# HELP node_network_rx_ring_size Number of descriptors of the rx ring of the device.
+# TYPE node_network_rx_ring_size gauge
+node_network_rx_ring_size{device="eth0"} 1024
+# HELP node_network_tx_ring_max Maximum number of descriptors of the tx ring of the device.
+# TYPE node_network_tx_ring_max gauge
+node_network_tx_ring_max{device="eth0"} 4096
+# HELP node_network_tx_ring_size Number of descriptors of the tx ring of the device.
+# TYPE node_network_tx_ring_size gauge
+node_network_tx_ring_size{device="eth0"} 512
+`
+	var opened, closed int
+	open := func() (ethtoolStats, error) {
+		opened++
+		return closeCountingEthtool{closed: &closed}, nil
+	}
+	c := newEthtoolCollector(open, nil, nil, regexp.MustCompile("_(errors|dropped)$"), log.NewNopLogger())
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+	if opened != 1 || closed != 1 {
+		t.Errorf("want the socket to be opened and closed once per scrape, got %d opens and %d closes", opened, closed)
+	}
+}
+
+func TestEthtoolIfreqSize(t *testing.T) {
+	// struct ifreq is the interface name followed by a 24 byte union.
+	if got, want := unsafe.Sizeof(ifreq{}), uintptr(unix.IFNAMSIZ+24); got != want {
+		t.Errorf("sizeof(ifreq) = %d, want %d", got, want)
+	}
+}
+
+func TestEthtoolRingParamsChannelsSize(t *testing.T) {
+	// struct ethtool_ringparam and struct ethtool_channels are both nine
+	// 32 bit words.
+	if got := unsafe.Sizeof(ethtoolRingParams{}); got != 36 {
+		t.Errorf("sizeof(ethtoolRingParams) = %d, want 36", got)
+	}
+	if got := unsafe.Sizeof(ethtoolChannels{}); got != 36 {
+		t.Errorf("sizeof(ethtoolChannels) = %d, want 36", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofibrechannel
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs/sysfs"
+)
+
+const fibrechannelSubsystem = "fibrechannel"
+
+type fibrechannelCollector struct {
+	fs          sysfs.FS
+	metricDescs map[string]*prometheus.Desc
+	infoDesc    *prometheus.Desc
+	logger      log.Logger
+}
+
+func init() {
+	registerCollector(fibrechannelSubsystem, defaultEnabled, NewFibreChannelCollector)
+}
+
+// NewFibreChannelCollector returns a new Collector exposing the port
+// statistics of the hosts in /sys/class/fc_host.
+func NewFibreChannelCollector(logger log.Logger) (Collector, error) {
+	fs, err := sysfs.NewFS(*sysPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sysfs: %w", err)
+	}
+
+	descriptions := map[string]string{
+		"dumped_frames_total":            "Number of frames dropped due to lack of host resources",
+		"error_frames_total":             "Number of frames received with errors",
+		"fcp_packet_aborts_total":        "Number of aborted FCP packets",
+		"invalid_crc_total":              "Number of frames received with an invalid CRC",
+		"invalid_tx_words_total":         "Number of invalid transmission words received",
+		"link_failure_total":             "Number of link failures",
+		"loss_of_signal_total":           "Number of times the signal was lost",
+		"loss_of_sync_total":             "Number of times the synchronization was lost",
+		"nos_total":                      "Number of not operational primitive sequences received",
+		"rx_frames_total":                "Number of frames received",
+		"rx_words_total":                 "Number of words received",
+		"seconds_since_last_reset_total": "Number of seconds since the statistics were last reset",
+		"tx_frames_total":                "Number of frames transmitted",
+		"tx_words_total":                 "Number of words transmitted",
+	}
+
+	metricDescs := make(map[string]*prometheus.Desc, len(descriptions))
+	for metricName, description := range descriptions {
+		metricDescs[metricName] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, fibrechannelSubsystem, metricName),
+			description,
+			[]string{"host"},
+			nil,
+		)
+	}
+
+	return &fibrechannelCollector{
+		fs:          fs,
+		metricDescs: metricDescs,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, fibrechannelSubsystem, "info"),
+			"Non-numeric data from /sys/class/fc_host/<host>, value is always 1.",
+			[]string{"host", "speed", "port_state", "port_type", "port_id", "port_name", "node_name", "fabric_name", "symbolic_name"},
+			nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+func (c *fibrechannelCollector) pushCounter(ch chan<- prometheus.Metric, name string, value uint64, host string) {
+	// Counters the driver doesn't support read as 0xffffffffffffffff.
+	if value == math.MaxUint64 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.metricDescs[name], prometheus.CounterValue, float64(value), host)
+}
+
+func (c *fibrechannelCollector) Update(ch chan<- prometheus.Metric) error {
+	hosts, err := c.fs.FibreChannelClass()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "fibrechannel statistics not found, skipping")
+			return ErrNoData
+		}
+		return fmt.Errorf("error obtaining FibreChannel class info: %w", err)
+	}
+
+	for _, host := range hosts {
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, host.Name, host.Speed, host.PortState, host.PortType, host.PortID, host.PortName, host.NodeName, host.FabricName, host.SymbolicName)
+
+		c.pushCounter(ch, "dumped_frames_total", host.Counters.DumpedFrames, host.Name)
+		c.pushCounter(ch, "error_frames_total", host.Counters.ErrorFrames, host.Name)
+		c.pushCounter(ch, "fcp_packet_aborts_total", host.Counters.FCPPacketAborts, host.Name)
+		c.pushCounter(ch, "invalid_crc_total", host.Counters.InvalidCRCCount, host.Name)
+		c.pushCounter(ch, "invalid_tx_words_total", host.Counters.InvalidTXWordCount, host.Name)
+		c.pushCounter(ch, "link_failure_total", host.Counters.LinkFailureCount, host.Name)
+		c.pushCounter(ch, "loss_of_signal_total", host.Counters.LossOfSignalCount, host.Name)
+		c.pushCounter(ch, "loss_of_sync_total", host.Counters.LossOfSyncCount, host.Name)
+		c.pushCounter(ch, "nos_total", host.Counters.NosCount, host.Name)
+		c.pushCounter(ch, "rx_frames_total", host.Counters.RXFrames, host.Name)
+		c.pushCounter(ch, "rx_words_total", host.Counters.RXWords, host.Name)
+		c.pushCounter(ch, "seconds_since_last_reset_total", host.Counters.SecondsSinceLastReset, host.Name)
+		c.pushCounter(ch, "tx_frames_total", host.Counters.TXFrames, host.Name)
+		c.pushCounter(ch, "tx_words_total", host.Counters.TXWords, host.Name)
+	}
+
+	return nil
+}
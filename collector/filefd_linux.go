@@ -28,6 +28,7 @@ import (
 
 const (
 	fileFDStatSubsystem = "filefd"
+	inodeStatSubsystem  = "inodes"
 )
 
 type fileFDStatCollector struct {
@@ -38,7 +39,8 @@ func init() {
 	registerCollector(fileFDStatSubsystem, defaultEnabled, NewFileFDStatCollector)
 }
 
-// NewFileFDStatCollector returns a new Collector exposing file-nr stats.
+// NewFileFDStatCollector returns a new Collector exposing file-nr and
+// inode-nr stats.
 func NewFileFDStatCollector(logger log.Logger) (Collector, error) {
 	return &fileFDStatCollector{logger}, nil
 }
@@ -62,6 +64,25 @@ func (c *fileFDStatCollector) Update(ch chan<- prometheus.Metric) error {
 			prometheus.GaugeValue, v,
 		)
 	}
+
+	inodeStat, err := parseInodeStats(procFilePath("sys/fs/inode-nr"))
+	if err != nil {
+		return fmt.Errorf("couldn't get inode-nr: %w", err)
+	}
+	for name, value := range inodeStat {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %s in inode-nr: %w", value, err)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, inodeStatSubsystem, name),
+				fmt.Sprintf("Inode cache statistics: %s.", name),
+				nil, nil,
+			),
+			prometheus.GaugeValue, v,
+		)
+	}
 	return nil
 }
 
@@ -89,3 +110,21 @@ func parseFileFDStats(filename string) (map[string]string, error) {
 
 	return fileFDStat, nil
 }
+
+func parseInodeStats(filename string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	parts := bytes.Fields(content)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("unexpected number of inode stats in %q", filename)
+	}
+
+	// The inode-nr proc is only 1 line with the number of allocated and
+	// free inodes.
+	return map[string]string{
+		"total": string(parts[0]),
+		"free":  string(parts[1]),
+	}, nil
+}
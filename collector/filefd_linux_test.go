@@ -29,3 +29,18 @@ func TestFileFDStats(t *testing.T) {
 		t.Errorf("want filefd maximum %q, got %q", want, got)
 	}
 }
+
+func TestInodeStats(t *testing.T) {
+	inodeStats, err := parseInodeStats("fixtures/proc/sys/fs/inode-nr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := "73860", inodeStats["total"]; want != got {
+		t.Errorf("want inodes total %q, got %q", want, got)
+	}
+
+	if want, got := "8261", inodeStats["free"]; want != got {
+		t.Errorf("want inodes free %q, got %q", want, got)
+	}
+}
@@ -12,12 +12,14 @@
 // limitations under the License.
 
 // +build !nofilesystem
-// +build linux freebsd openbsd darwin,amd64 dragonfly
+// +build linux freebsd openbsd darwin,amd64 dragonfly solaris
 
 package collector
 
 import (
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -42,6 +44,10 @@ var (
 	).Default(defIgnoredFSTypes).String()
 
 	filesystemLabelNames = []string{"device", "mountpoint", "fstype"}
+
+	// filesystemMountOptions are the mount options exposed as labels of
+	// node_filesystem_mount_info, others are left out to bound cardinality.
+	filesystemMountOptions = []string{"ro", "noexec", "nosuid"}
 )
 
 type filesystemCollector struct {
@@ -50,6 +56,7 @@ type filesystemCollector struct {
 	sizeDesc, freeDesc, availDesc *prometheus.Desc
 	filesDesc, filesFreeDesc      *prometheus.Desc
 	roDesc, deviceErrorDesc       *prometheus.Desc
+	mountInfoDesc                 *prometheus.Desc
 	logger                        log.Logger
 }
 
@@ -118,6 +125,12 @@ func NewFilesystemCollector(logger log.Logger) (Collector, error) {
 		filesystemLabelNames, nil,
 	)
 
+	mountInfoDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "mount_info"),
+		"Filesystem mount options, always 1.",
+		append(filesystemLabelNames, filesystemMountOptions...), nil,
+	)
+
 	return &filesystemCollector{
 		ignoredMountPointsPattern: mountPointPattern,
 		ignoredFSTypesPattern:     filesystemsTypesPattern,
@@ -128,6 +141,7 @@ func NewFilesystemCollector(logger log.Logger) (Collector, error) {
 		filesFreeDesc:             filesFreeDesc,
 		roDesc:                    roDesc,
 		deviceErrorDesc:           deviceErrorDesc,
+		mountInfoDesc:             mountInfoDesc,
 		logger:                    logger,
 	}, nil
 }
@@ -145,6 +159,15 @@ func (c *filesystemCollector) Update(ch chan<- prometheus.Metric) error {
 		}
 		seen[s.labels] = true
 
+		// Only platforms reading the mount table know the options.
+		if s.labels.options != "" {
+			ch <- prometheus.MustNewConstMetric(
+				c.mountInfoDesc, prometheus.GaugeValue, 1,
+				append([]string{s.labels.device, s.labels.mountPoint, s.labels.fsType},
+					mountOptionLabels(s.labels.options)...)...,
+			)
+		}
+
 		ch <- prometheus.MustNewConstMetric(
 			c.deviceErrorDesc, prometheus.GaugeValue,
 			s.deviceError, s.labels.device, s.labels.mountPoint, s.labels.fsType,
@@ -180,3 +203,18 @@ func (c *filesystemCollector) Update(ch chan<- prometheus.Metric) error {
 	}
 	return nil
 }
+
+// mountOptionLabels returns "true" or "false" for each of
+// filesystemMountOptions depending on whether it is in the comma separated
+// options.
+func mountOptionLabels(options string) []string {
+	set := map[string]bool{}
+	for _, option := range strings.Split(options, ",") {
+		set[option] = true
+	}
+	labels := make([]string, len(filesystemMountOptions))
+	for i, option := range filesystemMountOptions {
+		labels[i] = strconv.FormatBool(set[option])
+	}
+	return labels
+}
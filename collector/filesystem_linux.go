@@ -38,10 +38,13 @@ const (
 
 var mountTimeout = kingpin.Flag("collector.filesystem.mount-timeout",
 	"how long to wait for a mount to respond before marking it as stale").
-	Hidden().Default("5s").Duration()
+	Default("5s").Duration()
 var stuckMounts = make(map[string]struct{})
 var stuckMountsMtx = &sync.Mutex{}
 
+// statfs is unix.Statfs, it is replaced in tests.
+var statfs = unix.Statfs
+
 // GetStats returns filesystem stats.
 func (c *filesystemCollector) GetStats() ([]filesystemStats, error) {
 	mps, err := mountPointDetails(c.logger)
@@ -70,22 +73,14 @@ func (c *filesystemCollector) GetStats() ([]filesystemStats, error) {
 		}
 		stuckMountsMtx.Unlock()
 
-		// The success channel is used do tell the "watcher" that the stat
-		// finished successfully. The channel is closed on success.
-		success := make(chan struct{})
-		go stuckMountWatcher(labels.mountPoint, success, c.logger)
-
-		buf := new(unix.Statfs_t)
-		err = unix.Statfs(rootfsFilePath(labels.mountPoint), buf)
-		stuckMountsMtx.Lock()
-		close(success)
-		// If the mount has been marked as stuck, unmark it and log it's recovery.
-		if _, ok := stuckMounts[labels.mountPoint]; ok {
-			level.Debug(c.logger).Log("msg", "Mount point has recovered, monitoring will resume", "mountpoint", labels.mountPoint)
-			delete(stuckMounts, labels.mountPoint)
+		buf, err := statfsWithTimeout(labels.mountPoint, c.logger)
+		if errors.Is(err, errMountTimeout) {
+			stats = append(stats, filesystemStats{
+				labels:      labels,
+				deviceError: 1,
+			})
+			continue
 		}
-		stuckMountsMtx.Unlock()
-
 		if err != nil {
 			stats = append(stats, filesystemStats{
 				labels:      labels,
@@ -117,24 +112,50 @@ func (c *filesystemCollector) GetStats() ([]filesystemStats, error) {
 	return stats, nil
 }
 
-// stuckMountWatcher listens on the given success channel and if the channel closes
-// then the watcher does nothing. If instead the timeout is reached, the
-// mount point that is being watched is marked as stuck.
-func stuckMountWatcher(mountPoint string, success chan struct{}, logger log.Logger) {
+var errMountTimeout = errors.New("mount point timed out")
+
+type statfsResult struct {
+	buf *unix.Statfs_t
+	err error
+}
+
+// statfsWithTimeout calls statfs() for the mount point in a goroutine and
+// gives up after the mount timeout, marking the mount point as stuck. The
+// goroutine clears the mark once statfs() eventually returns, so the mount
+// point is monitored again from the next scrape on.
+func statfsWithTimeout(mountPoint string, logger log.Logger) (*unix.Statfs_t, error) {
+	// The result channel is buffered so the goroutine can always finish,
+	// even if nobody is waiting for its result anymore.
+	result := make(chan statfsResult, 1)
+	go func() {
+		buf := new(unix.Statfs_t)
+		err := statfs(rootfsFilePath(mountPoint), buf)
+		result <- statfsResult{buf: buf, err: err}
+
+		stuckMountsMtx.Lock()
+		// If the mount has been marked as stuck, unmark it and log it's recovery.
+		if _, ok := stuckMounts[mountPoint]; ok {
+			level.Debug(logger).Log("msg", "Mount point has recovered, monitoring will resume", "mountpoint", mountPoint)
+			delete(stuckMounts, mountPoint)
+		}
+		stuckMountsMtx.Unlock()
+	}()
+
 	select {
-	case <-success:
-		// Success
+	case r := <-result:
+		return r.buf, r.err
 	case <-time.After(*mountTimeout):
-		// Timed out, mark mount as stuck
 		stuckMountsMtx.Lock()
+		defer stuckMountsMtx.Unlock()
 		select {
-		case <-success:
+		case r := <-result:
 			// Success came in just after the timeout was reached, don't label the mount as stuck
+			return r.buf, r.err
 		default:
 			level.Debug(logger).Log("msg", "Mount point timed out, it is being labeled as stuck and will not be monitored", "mountpoint", mountPoint)
 			stuckMounts[mountPoint] = struct{}{}
+			return nil, errMountTimeout
 		}
-		stuckMountsMtx.Unlock()
 	}
 }
 
@@ -17,7 +17,9 @@ import (
 	"github.com/go-kit/kit/log"
 	"strings"
 	"testing"
+	"time"
 
+	"golang.org/x/sys/unix"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -139,3 +141,70 @@ func TestPathRootfs(t *testing.T) {
 		}
 	}
 }
+
+func TestMountOptionLabels(t *testing.T) {
+	tests := map[string][]string{
+		"rw,relatime":                     {"false", "false", "false"},
+		"ro,nosuid,nodev,noexec,relatime": {"true", "true", "true"},
+		"rw,nosuid,relatime,mode=755":     {"false", "false", "true"},
+		"rw,noexecute,rootcontext=nosuid": {"false", "false", "false"},
+	}
+
+	for options, want := range tests {
+		got := mountOptionLabels(options)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: want %v, got %v", options, want, got)
+		}
+	}
+}
+
+func TestStatfsWithTimeout(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.rootfs", "/", "--collector.filesystem.mount-timeout", "10ms"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	// The stub hangs for the stuck mount point until release is closed.
+	release := make(chan struct{})
+	defer func(f func(string, *unix.Statfs_t) error) { statfs = f }(statfs)
+	statfs = func(path string, buf *unix.Statfs_t) error {
+		if path == "/stuck" {
+			<-release
+		}
+		buf.Blocks = 42
+		return nil
+	}
+	isStuck := func(mountPoint string) bool {
+		stuckMountsMtx.Lock()
+		defer stuckMountsMtx.Unlock()
+		_, ok := stuckMounts[mountPoint]
+		return ok
+	}
+
+	buf, err := statfsWithTimeout("/fine", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Blocks != 42 {
+		t.Errorf("want 42 blocks, got %d", buf.Blocks)
+	}
+	if isStuck("/fine") {
+		t.Error("responsive mount point marked as stuck")
+	}
+
+	if _, err := statfsWithTimeout("/stuck", log.NewNopLogger()); err != errMountTimeout {
+		t.Fatalf("want errMountTimeout for a hanging statfs, got %v", err)
+	}
+	if !isStuck("/stuck") {
+		t.Fatal("hanging mount point not marked as stuck")
+	}
+
+	// The mark is cleared once the hanging statfs returns.
+	close(release)
+	for deadline := time.Now().Add(5 * time.Second); isStuck("/stuck"); {
+		if time.Now().After(deadline) {
+			t.Fatal("stuck mark not cleared after statfs returned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
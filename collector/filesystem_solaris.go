@@ -0,0 +1,114 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofilesystem
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	defIgnoredMountPoints = "^/(dev|devices|proc|system)($|/)"
+	defIgnoredFSTypes     = "^(autofs|bootfs|ctfs|dev|devfs|fd|mntfs|objfs|proc|sharefs)$"
+	readOnly              = 0x1 // ST_RDONLY
+)
+
+// GetStats returns filesystem stats.
+func (c *filesystemCollector) GetStats() ([]filesystemStats, error) {
+	mps, err := mountPointDetails()
+	if err != nil {
+		return nil, err
+	}
+	stats := []filesystemStats{}
+	for _, labels := range mps {
+		if c.ignoredMountPointsPattern.MatchString(labels.mountPoint) {
+			level.Debug(c.logger).Log("msg", "Ignoring mount point", "mountpoint", labels.mountPoint)
+			continue
+		}
+		if c.ignoredFSTypesPattern.MatchString(labels.fsType) {
+			level.Debug(c.logger).Log("msg", "Ignoring fs", "type", labels.fsType)
+			continue
+		}
+
+		buf := new(unix.Statvfs_t)
+		if err := unix.Statvfs(rootfsFilePath(labels.mountPoint), buf); err != nil {
+			stats = append(stats, filesystemStats{
+				labels:      labels,
+				deviceError: 1,
+			})
+
+			level.Debug(c.logger).Log("msg", "Error on statvfs() system call", "rootfs", rootfsFilePath(labels.mountPoint), "err", err)
+			continue
+		}
+
+		var ro float64
+		if (buf.Flag & readOnly) != 0 {
+			ro = 1
+		}
+
+		// Block counts are in units of the fragment size.
+		stats = append(stats, filesystemStats{
+			labels:    labels,
+			size:      float64(buf.Blocks) * float64(buf.Frsize),
+			free:      float64(buf.Bfree) * float64(buf.Frsize),
+			avail:     float64(buf.Bavail) * float64(buf.Frsize),
+			files:     float64(buf.Files),
+			filesFree: float64(buf.Ffree),
+			ro:        ro,
+		})
+	}
+	return stats, nil
+}
+
+func mountPointDetails() ([]filesystemLabels, error) {
+	file, err := os.Open(rootfsFilePath("/etc/mnttab"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseMnttab(file)
+}
+
+// parseMnttab parses mnttab(4): one mount per line, with tab separated
+// special, mount point, fstype, options and time fields.
+func parseMnttab(r io.Reader) ([]filesystemLabels, error) {
+	var filesystems []filesystemLabels
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), "\t")
+
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("malformed mount point information: %q", scanner.Text())
+		}
+
+		filesystems = append(filesystems, filesystemLabels{
+			device:     parts[0],
+			mountPoint: rootfsStripPrefix(parts[1]),
+			fsType:     parts[2],
+			options:    parts[3],
+		})
+	}
+
+	return filesystems, scanner.Err()
+}
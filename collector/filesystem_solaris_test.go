@@ -0,0 +1,94 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofilesystem
+
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestParseMnttab(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.rootfs", "/"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	tests := []struct {
+		name string
+		in   string
+		want []filesystemLabels
+		err  bool
+	}{
+		{
+			name: "mount points",
+			in: "rpool/ROOT/solaris\t/\tzfs\tdev=4850002\t1588000000\n" +
+				"/devices\t/devices\tdevfs\tdev=8580000\t1588000000\n" +
+				"swap\t/tmp\ttmpfs\txattr,dev=85c0002\t1588000001\n",
+			want: []filesystemLabels{
+				{device: "rpool/ROOT/solaris", mountPoint: "/", fsType: "zfs", options: "dev=4850002"},
+				{device: "/devices", mountPoint: "/devices", fsType: "devfs", options: "dev=8580000"},
+				{device: "swap", mountPoint: "/tmp", fsType: "tmpfs", options: "xattr,dev=85c0002"},
+			},
+		},
+		{
+			name: "without time",
+			in:   "swap\t/tmp\ttmpfs\txattr\n",
+			want: []filesystemLabels{
+				{device: "swap", mountPoint: "/tmp", fsType: "tmpfs", options: "xattr"},
+			},
+		},
+		{
+			name: "empty",
+			in:   "",
+		},
+		{
+			name: "too few fields",
+			in:   "swap\t/tmp\ttmpfs\n",
+			err:  true,
+		},
+		{
+			name: "space separated",
+			in:   "swap /tmp tmpfs xattr 1588000001\n",
+			err:  true,
+		},
+		{
+			name: "malformed line after valid one",
+			in:   "swap\t/tmp\ttmpfs\txattr\t1588000001\n\n",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMnttab(strings.NewReader(tt.in))
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error, but none occurred")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohttp
+
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const httpSubsystem = "http"
+
+var (
+	httpTargets            = kingpin.Flag("collector.http.targets", "Comma separated list of URLs to probe for the http collector.").Default("").String()
+	httpTimeout            = kingpin.Flag("collector.http.timeout", "Timeout for probing a single URL.").Default("5s").Duration()
+	httpFollowRedirects    = kingpin.Flag("collector.http.follow-redirects", "Follow redirects of the probed URLs.").Default("true").Bool()
+	httpInsecureSkipVerify = kingpin.Flag("collector.http.insecure-skip-verify", "Don't verify the TLS certificates of the probed URLs.").Default("false").Bool()
+)
+
+type httpCollector struct {
+	targets []httpTarget
+	client  *http.Client
+
+	success, duration, statusCode typedDesc
+	logger                        log.Logger
+}
+
+// httpTarget is a URL to probe, label is the URL without its user info so
+// credentials don't end up in the target label.
+type httpTarget struct {
+	url   string
+	label string
+}
+
+type httpProbeResult struct {
+	target     httpTarget
+	duration   time.Duration
+	statusCode int
+	err        error
+}
+
+func init() {
+	registerCollector(httpSubsystem, defaultDisabled, NewHTTPCollector)
+}
+
+// NewHTTPCollector returns a new Collector probing the configured URLs.
+func NewHTTPCollector(logger log.Logger) (Collector, error) {
+	var targets []httpTarget
+	// Targets with the same label would be exposed as duplicate series.
+	seen := map[string]bool{}
+	for _, target := range strings.Split(*httpTargets, ",") {
+		if target = strings.TrimSpace(target); target == "" {
+			continue
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --collector.http.targets URL: %w", err)
+		}
+		u.User = nil
+		label := u.String()
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		targets = append(targets, httpTarget{url: target, label: label})
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: *httpInsecureSkipVerify}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   *httpTimeout,
+	}
+	if !*httpFollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return &httpCollector{
+		targets: targets,
+		client:  client,
+		success: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, httpSubsystem, "probe_success"),
+			"Whether the target answered with a 2xx status code.",
+			[]string{"target"}, nil,
+		), prometheus.GaugeValue},
+		duration: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, httpSubsystem, "probe_duration_seconds"),
+			"Time taken to probe the target, including the response body.",
+			[]string{"target"}, nil,
+		), prometheus.GaugeValue},
+		statusCode: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, httpSubsystem, "probe_status_code"),
+			"HTTP status code of the response, 0 if there was none.",
+			[]string{"target"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *httpCollector) Update(ch chan<- prometheus.Metric) error {
+	return c.UpdateContext(context.Background(), ch)
+}
+
+// UpdateContext implements ContextCollector. The targets are probed in
+// parallel so a slow one doesn't hold up the others.
+func (c *httpCollector) UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if len(c.targets) == 0 {
+		level.Debug(c.logger).Log("msg", "No --collector.http.targets configured, skipping")
+		return ErrNoData
+	}
+
+	results := make([]httpProbeResult, len(c.targets))
+	var wg sync.WaitGroup
+	for i, target := range c.targets {
+		wg.Add(1)
+		go func(i int, target httpTarget) {
+			defer wg.Done()
+			results[i] = c.probe(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		success := 0.0
+		if r.err != nil {
+			level.Debug(c.logger).Log("msg", "Failed to probe target", "target", r.target.label, "err", r.err)
+		} else if r.statusCode >= 200 && r.statusCode < 300 {
+			success = 1
+		}
+		ch <- c.success.mustNewConstMetric(success, r.target.label)
+		ch <- c.duration.mustNewConstMetric(r.duration.Seconds(), r.target.label)
+		ch <- c.statusCode.mustNewConstMetric(float64(r.statusCode), r.target.label)
+	}
+	return nil
+}
+
+func (c *httpCollector) probe(ctx context.Context, target httpTarget) (result httpProbeResult) {
+	result.target = target
+	begin := time.Now()
+	defer func() {
+		result.duration = time.Since(begin)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.url, nil)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.statusCode = resp.StatusCode
+	_, result.err = io.Copy(ioutil.Discard, resp.Body)
+	return result
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohugepages
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// hugepagesFiles maps the files of a hugepages-<size> directory to the
+// exposed metrics and their help.
+var hugepagesFiles = map[string][2]string{
+	"nr_hugepages":      {"hugepages_total", "Number of huge pages in the pool."},
+	"free_hugepages":    {"hugepages_free", "Number of huge pages in the pool that are not allocated."},
+	"resv_hugepages":    {"hugepages_reserved", "Number of huge pages reserved but not allocated yet."},
+	"surplus_hugepages": {"hugepages_surplus", "Number of huge pages above nr_hugepages allocated by overcommit."},
+}
+
+type hugepagesCollector struct {
+	descs  map[string]*prometheus.Desc
+	logger log.Logger
+}
+
+func init() {
+	registerCollector("hugepages", defaultEnabled, NewHugepagesCollector)
+}
+
+// NewHugepagesCollector returns a new Collector exposing the huge page pools
+// of all page sizes from /sys/kernel/mm/hugepages.
+func NewHugepagesCollector(logger log.Logger) (Collector, error) {
+	descs := make(map[string]*prometheus.Desc, len(hugepagesFiles))
+	for file, metric := range hugepagesFiles {
+		descs[file] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "memory", metric[0]),
+			metric[1],
+			[]string{"size"}, nil,
+		)
+	}
+	return &hugepagesCollector{
+		descs:  descs,
+		logger: logger,
+	}, nil
+}
+
+func (c *hugepagesCollector) Update(ch chan<- prometheus.Metric) error {
+	dir := sysFilePath("kernel/mm/hugepages")
+	pools, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			level.Debug(c.logger).Log("msg", "Huge pages not supported, skipping")
+			return ErrNoData
+		}
+		return err
+	}
+
+	for _, pool := range pools {
+		size := strings.TrimPrefix(pool.Name(), "hugepages-")
+		if size == pool.Name() {
+			continue
+		}
+		for file, desc := range c.descs {
+			value, err := readUintFromFile(filepath.Join(dir, pool.Name(), file))
+			if err != nil {
+				return fmt.Errorf("couldn't read huge pages of size %s: %w", size, err)
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value), size)
+		}
+	}
+	return nil
+}
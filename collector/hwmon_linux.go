@@ -170,7 +170,7 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 	if err == nil {
 		// sensor chip metadata
 		desc := prometheus.NewDesc(
-			"node_hwmon_chip_names",
+			prometheus.BuildFQName(namespace, "hwmon", "chip_names"),
 			"Annotation metric for human-readable chip names",
 			hwmonChipNameLabelDesc,
 			nil,
@@ -194,7 +194,7 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 		if labelText, ok := sensorData["label"]; ok {
 			label := cleanMetricName(labelText)
 			if label != "" {
-				desc := prometheus.NewDesc("node_hwmon_sensor_label", "Label for given chip and sensor",
+				desc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "hwmon", "sensor_label"), "Label for given chip and sensor",
 					[]string{"chip", "sensor", "label"}, nil)
 				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1.0, hwmonName, sensor, label)
 			}
@@ -205,7 +205,7 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 			if sensorData[""] == "1" {
 				value = 1.0
 			}
-			metricName := "node_hwmon_beep_enabled"
+			metricName := prometheus.BuildFQName(namespace, "hwmon", "beep_enabled")
 			desc := prometheus.NewDesc(metricName, "Hardware beep enabled", hwmonLabelDesc, nil)
 			ch <- prometheus.MustNewConstMetric(
 				desc, prometheus.GaugeValue, value, labels...)
@@ -216,7 +216,7 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 			if err != nil {
 				continue
 			}
-			metricName := "node_hwmon_voltage_regulator_version"
+			metricName := prometheus.BuildFQName(namespace, "hwmon", "voltage_regulator_version")
 			desc := prometheus.NewDesc(metricName, "Hardware voltage regulator", hwmonLabelDesc, nil)
 			ch <- prometheus.MustNewConstMetric(
 				desc, prometheus.GaugeValue, parsedValue, labels...)
@@ -227,14 +227,14 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 			if err != nil {
 				continue
 			}
-			metricName := "node_hwmon_update_interval_seconds"
+			metricName := prometheus.BuildFQName(namespace, "hwmon", "update_interval_seconds")
 			desc := prometheus.NewDesc(metricName, "Hardware monitor update interval", hwmonLabelDesc, nil)
 			ch <- prometheus.MustNewConstMetric(
 				desc, prometheus.GaugeValue, parsedValue*0.001, labels...)
 			continue
 		}
 
-		prefix := "node_hwmon_" + sensorType
+		prefix := prometheus.BuildFQName(namespace, "hwmon", sensorType)
 
 		for element, value := range sensorData {
 
@@ -329,6 +329,13 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) er
 				continue
 			}
 
+			if sensorType == "pwm" && element == "" {
+				desc := prometheus.NewDesc(name, "Hardware monitor pwm duty cycle, from 0 to 255", hwmonLabelDesc, nil)
+				ch <- prometheus.MustNewConstMetric(
+					desc, prometheus.GaugeValue, parsedValue, labels...)
+				continue
+			}
+
 			// fallback, just dump the metric as is
 
 			desc := prometheus.NewDesc(name, "Hardware monitor "+sensorType+" element "+element, hwmonLabelDesc, nil)
@@ -54,11 +54,11 @@ func NewInfiniBandCollector(logger log.Logger) (Collector, error) {
 	descriptions := map[string]string{
 		"legacy_multicast_packets_received_total":    "Number of multicast packets received",
 		"legacy_multicast_packets_transmitted_total": "Number of multicast packets transmitted",
-		"legacy_data_received_bytes_total":           "Number of data octets received on all links",
+		"legacy_data_received_bytes_total":           "Number of data octets received on all links (sysfs counts 32 bit words, these are multiplied by 4)",
 		"legacy_packets_received_total":              "Number of data packets received on all links",
 		"legacy_unicast_packets_received_total":      "Number of unicast packets received",
 		"legacy_unicast_packets_transmitted_total":   "Number of unicast packets transmitted",
-		"legacy_data_transmitted_bytes_total":        "Number of data octets transmitted on all links",
+		"legacy_data_transmitted_bytes_total":        "Number of data octets transmitted on all links (sysfs counts 32 bit words, these are multiplied by 4)",
 		"legacy_packets_transmitted_total":           "Number of data packets received on all links",
 		"link_downed_total":                          "Number of times the link failed to recover from an error state and went down",
 		"link_error_recovery_total":                  "Number of times the link successfully recovered from an error state",
@@ -67,8 +67,8 @@ func NewInfiniBandCollector(logger log.Logger) (Collector, error) {
 		"physical_state_id":                          "Physical state of the InfiniBand port (0: no change, 1: sleep, 2: polling, 3: disable, 4: shift, 5: link up, 6: link error recover, 7: phytest)",
 		"port_constraint_errors_received_total":      "Number of packets received on the switch physical port that are discarded",
 		"port_constraint_errors_transmitted_total":   "Number of packets not transmitted from the switch physical port",
-		"port_data_received_bytes_total":             "Number of data octets received on all links",
-		"port_data_transmitted_bytes_total":          "Number of data octets transmitted on all links",
+		"port_data_received_bytes_total":             "Number of data octets received on all links (sysfs counts 32 bit words, these are multiplied by 4)",
+		"port_data_transmitted_bytes_total":          "Number of data octets transmitted on all links (sysfs counts 32 bit words, these are multiplied by 4)",
 		"port_discards_received_total":               "Number of inbound packets discarded by the port because the port is down or congested",
 		"port_discards_transmitted_total":            "Number of outbound packets discarded by the port because the port is down or congested",
 		"port_errors_received_total":                 "Number of packets containing an error that were received on this port",
@@ -17,13 +17,23 @@
 package collector
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	irqInclude = kingpin.Flag("collector.interrupts.include-irqs", "Regexp of interrupts to include, matched against the type label. That is the IRQ number or name like NMI on Linux and the interrupt vector number on OpenBSD.").Default(".+").String()
 )
 
 type interruptsCollector struct {
-	desc   typedDesc
-	logger log.Logger
+	desc       typedDesc
+	irqInclude *regexp.Regexp
+	logger     log.Logger
 }
 
 func init() {
@@ -32,12 +42,19 @@ func init() {
 
 // NewInterruptsCollector returns a new Collector exposing interrupts stats.
 func NewInterruptsCollector(logger log.Logger) (Collector, error) {
+	level.Info(logger).Log("msg", "Parsed flag --collector.interrupts.include-irqs", "flag", *irqInclude)
+	pattern, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", *irqInclude))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --collector.interrupts.include-irqs: %w", err)
+	}
+
 	return &interruptsCollector{
 		desc: typedDesc{prometheus.NewDesc(
 			namespace+"_interrupts_total",
 			"Interrupt details.",
 			interruptLabelNames, nil,
 		), prometheus.CounterValue},
-		logger: logger,
+		irqInclude: pattern,
+		logger:     logger,
 	}, nil
 }
@@ -29,6 +29,11 @@ import (
 
 var (
 	interruptLabelNames = []string{"cpu", "type", "info", "devices"}
+
+	// summaryInterrupts are the rows with a single total instead of one
+	// column per cpu, which can't be told apart by their columns on single
+	// cpu hosts.
+	summaryInterrupts = map[string]bool{"ERR": true, "MIS": true, "Err": true}
 )
 
 func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -37,12 +42,19 @@ func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		return fmt.Errorf("couldn't get interrupts: %w", err)
 	}
 	for name, interrupt := range interrupts {
+		if !c.irqInclude.MatchString(name) {
+			continue
+		}
 		for cpuNo, value := range interrupt.values {
 			fv, err := strconv.ParseFloat(value, 64)
 			if err != nil {
 				return fmt.Errorf("invalid value %s in interrupts: %w", value, err)
 			}
-			ch <- c.desc.mustNewConstMetric(fv, strconv.Itoa(cpuNo), name, interrupt.info, interrupt.devices)
+			cpu := strconv.Itoa(cpuNo)
+			if interrupt.summary {
+				cpu = ""
+			}
+			ch <- c.desc.mustNewConstMetric(fv, cpu, name, interrupt.info, interrupt.devices)
 		}
 	}
 	return err
@@ -52,6 +64,9 @@ type interrupt struct {
 	info    string
 	devices string
 	values  []string
+	// summary is set for rows like ERR and MIS that only have a single
+	// value for all CPUs.
+	summary bool
 }
 
 func getInterrupts() (map[string]interrupt, error) {
@@ -77,15 +92,24 @@ func parseInterrupts(r io.Reader) (map[string]interrupt, error) {
 
 	for scanner.Scan() {
 		parts := strings.Fields(scanner.Text())
-		if len(parts) < cpuNum+2 { // irq + one column per cpu + details,
-			continue // we ignore ERR and MIS for now
+		if len(parts) < 2 || !strings.HasSuffix(parts[0], ":") {
+			continue
 		}
 		intName := parts[0][:len(parts[0])-1] // remove trailing :
+		// Summary rows such as ERR and MIS only have a total instead of one
+		// column per cpu.
+		if len(parts) < cpuNum+1 || summaryInterrupts[intName] {
+			interrupts[intName] = interrupt{
+				values:  parts[1:2],
+				summary: true,
+			}
+			continue
+		}
 		intr := interrupt{
 			values: parts[1 : cpuNum+1],
 		}
 
-		if _, err := strconv.Atoi(intName); err == nil { // numeral interrupt
+		if _, err := strconv.Atoi(intName); err == nil && len(parts) > cpuNum+1 { // numeral interrupt
 			intr.info = parts[cpuNum+1]
 			intr.devices = strings.Join(parts[cpuNum+2:], " ")
 		} else {
@@ -37,4 +37,48 @@ func TestInterrupts(t *testing.T) {
 	if want, got := "4968", interrupts["NMI"].values[3]; want != got {
 		t.Errorf("want interrupts %s, got %s", want, got)
 	}
+
+	if want, got := []string{"0"}, interrupts["ERR"].values; len(got) != 1 || want[0] != got[0] {
+		t.Errorf("want interrupts %v, got %v", want, got)
+	}
+
+	if !interrupts["MIS"].summary {
+		t.Errorf("want MIS to be a summary row")
+	}
+}
+
+func TestInterruptsSingleCPU(t *testing.T) {
+	file, err := os.Open("fixtures_single_cpu/proc/interrupts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	interrupts, err := parseInterrupts(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"ERR", "MIS"} {
+		if !interrupts[name].summary {
+			t.Errorf("want %s to be a summary row", name)
+		}
+	}
+	if want, got := []string{"3"}, interrupts["ERR"].values; len(got) != 1 || want[0] != got[0] {
+		t.Errorf("want interrupts %v, got %v", want, got)
+	}
+
+	if interrupts["LOC"].summary {
+		t.Error("want LOC to be a per cpu row")
+	}
+	if want, got := "1865019", interrupts["LOC"].values[0]; want != got {
+		t.Errorf("want interrupts %s, got %s", want, got)
+	}
+	if want, got := "Local timer interrupts", interrupts["LOC"].info; want != got {
+		t.Errorf("want info %q, got %q", want, got)
+	}
+
+	if want, got := "virtio0, virtio1", interrupts["11"].devices; want != got {
+		t.Errorf("want devices %q, got %q", want, got)
+	}
 }
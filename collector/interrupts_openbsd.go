@@ -104,6 +104,11 @@ func (c *interruptsCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("couldn't get interrupts: %w", err)
 	}
 	for dev, interrupt := range interrupts {
+		// Like on Linux the type label is matched, which holds the vector
+		// here since OpenBSD interrupts have no name.
+		if !c.irqInclude.MatchString(strconv.Itoa(interrupt.vector)) {
+			continue
+		}
 		for cpuNo, value := range interrupt.values {
 			ch <- c.desc.mustNewConstMetric(
 				value,
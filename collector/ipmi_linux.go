@@ -0,0 +1,519 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noipmi
+
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const ipmiSubsystem = "ipmi"
+
+// Constants from linux/ipmi.h and the IPMI 2.0 specification.
+const (
+	ipmiSystemInterfaceAddrType = 0x0c
+	ipmiBMCChannel              = 0x0f
+	ipmiBMCSlaveAddr            = 0x20
+	ipmiTimeoutMs               = 5000
+
+	ipmiNetFnSensor  = 0x04
+	ipmiNetFnStorage = 0x0a
+
+	ipmiCmdGetSensorReading = 0x2d
+	ipmiCmdReserveSDR       = 0x22
+	ipmiCmdGetSDR           = 0x23
+
+	ipmiCompletionOK                  = 0x00
+	ipmiCompletionReservationCanceled = 0xc5
+
+	ipmiSDRTypeFullSensor   = 0x01
+	ipmiSDRHeaderLength     = 5
+	ipmiSDRChunkLength      = 16
+	ipmiSDRLastRecord       = 0xffff
+	ipmiSDRMaxRecords       = 1024
+	ipmiEventTypeThreshold  = 0x01
+	ipmiReadingUnavailable  = 0x20
+	ipmiAnalogFormatNone    = 0x03
+	ipmiAnalogFormatOnes    = 0x01
+	ipmiAnalogFormatTwos    = 0x02
+	ipmiUnitCelsius         = 1
+	ipmiUnitFahrenheit      = 2
+	ipmiUnitKelvin          = 3
+	ipmiUnitVolts           = 4
+	ipmiUnitWatts           = 6
+	ipmiUnitRPM             = 18
+	ipmiMaxReservationRetry = 3
+)
+
+// ipmiMsg, ipmiReq, ipmiRecv and ipmiSystemInterfaceAddr are struct ipmi_msg,
+// struct ipmi_req, struct ipmi_recv and struct ipmi_system_interface_addr from
+// linux/ipmi.h. C long is the size of int on Linux.
+type ipmiMsg struct {
+	netfn   uint8
+	cmd     uint8
+	dataLen uint16
+	data    uintptr
+}
+
+type ipmiReq struct {
+	addr    uintptr
+	addrLen uint32
+	msgid   int
+	msg     ipmiMsg
+}
+
+type ipmiRecv struct {
+	recvType int32
+	addr     uintptr
+	addrLen  uint32
+	msgid    int
+	msg      ipmiMsg
+}
+
+type ipmiSystemInterfaceAddr struct {
+	addrType int32
+	channel  int16
+	lun      uint8
+}
+
+// ipmiIoctl returns the ioctl request number of the IPMI device, the layout
+// of the direction bits depends on the architecture.
+func ipmiIoctl(dir, nr, size uintptr) uintptr {
+	return dir<<iocDirShift | size<<16 | 'i'<<8 | nr
+}
+
+var (
+	ipmiCtlReceiveMsgTrunc = ipmiIoctl(iocRead|iocWrite, 11, unsafe.Sizeof(ipmiRecv{}))
+	ipmiCtlSendCommand     = ipmiIoctl(iocRead, 13, unsafe.Sizeof(ipmiReq{}))
+)
+
+// ipmiConn sends a request to the BMC and returns the completion code and
+// data of the response.
+type ipmiConn interface {
+	command(netfn, cmd, lun uint8, data []byte) (uint8, []byte, error)
+	Close() error
+}
+
+// ipmiSensor is an analog sensor of a full sensor record of the SDR
+// repository, with the factors to convert its readings.
+type ipmiSensor struct {
+	// id is the record ID, BMCs often have several sensors of the same name.
+	id            uint16
+	name          string
+	number, lun   uint8
+	unit          uint8
+	analogFormat  uint8
+	linearization uint8
+	m, b          int
+	rExp, bExp    int
+}
+
+type ipmiCollector struct {
+	// open connects to the BMC, it is replaced in tests.
+	open func() (ipmiConn, error)
+
+	// The sensors of the SDR repository rarely change, so they are read on
+	// the first successful scrape only.
+	mtx     sync.Mutex
+	sensors []ipmiSensor
+
+	temperature, fanSpeed, voltage, power typedDesc
+	logger                                log.Logger
+}
+
+func init() {
+	registerCollector(ipmiSubsystem, defaultDisabled, NewIPMICollector)
+}
+
+// NewIPMICollector returns a new Collector exposing the sensors of the BMC
+// through the IPMI device of the kernel.
+func NewIPMICollector(logger log.Logger) (Collector, error) {
+	return &ipmiCollector{
+		open: openIPMIDevice,
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ipmiSubsystem, "temperature_celsius"),
+			"Reading of an IPMI temperature sensor.",
+			[]string{"id", "sensor"}, nil,
+		), prometheus.GaugeValue},
+		fanSpeed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ipmiSubsystem, "fan_speed_rpm"),
+			"Reading of an IPMI fan speed sensor.",
+			[]string{"id", "sensor"}, nil,
+		), prometheus.GaugeValue},
+		voltage: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ipmiSubsystem, "voltage_volts"),
+			"Reading of an IPMI voltage sensor.",
+			[]string{"id", "sensor"}, nil,
+		), prometheus.GaugeValue},
+		power: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ipmiSubsystem, "power_watts"),
+			"Reading of an IPMI power sensor.",
+			[]string{"id", "sensor"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *ipmiCollector) Update(ch chan<- prometheus.Metric) error {
+	conn, err := c.open()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+			level.Debug(c.logger).Log("msg", "Couldn't open IPMI device, skipping", "err", err)
+			return ErrNoData
+		}
+		return fmt.Errorf("couldn't open IPMI device: %w", err)
+	}
+	defer conn.Close()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.sensors == nil {
+		sensors, err := readIPMISensors(conn)
+		if err != nil {
+			return fmt.Errorf("couldn't read SDR repository: %w", err)
+		}
+		c.sensors = sensors
+	}
+
+	for _, s := range c.sensors {
+		id := strconv.Itoa(int(s.id))
+		value, ok, err := s.read(conn)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't read IPMI sensor", "sensor", s.name, "err", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		switch s.unit {
+		case ipmiUnitCelsius:
+			ch <- c.temperature.mustNewConstMetric(value, id, s.name)
+		case ipmiUnitFahrenheit:
+			ch <- c.temperature.mustNewConstMetric((value-32)*5/9, id, s.name)
+		case ipmiUnitKelvin:
+			ch <- c.temperature.mustNewConstMetric(value-273.15, id, s.name)
+		case ipmiUnitRPM:
+			ch <- c.fanSpeed.mustNewConstMetric(value, id, s.name)
+		case ipmiUnitVolts:
+			ch <- c.voltage.mustNewConstMetric(value, id, s.name)
+		case ipmiUnitWatts:
+			ch <- c.power.mustNewConstMetric(value, id, s.name)
+		}
+	}
+	return nil
+}
+
+// read returns the converted reading of the sensor, ok is false if the BMC
+// has no reading.
+func (s ipmiSensor) read(conn ipmiConn) (value float64, ok bool, err error) {
+	cc, data, err := conn.command(ipmiNetFnSensor, ipmiCmdGetSensorReading, s.lun, []byte{s.number})
+	if err != nil {
+		return 0, false, err
+	}
+	// Sensors of absent devices answer with various completion codes.
+	if cc != ipmiCompletionOK || len(data) < 2 || data[1]&ipmiReadingUnavailable != 0 {
+		return 0, false, nil
+	}
+	return s.convert(data[0]), true, nil
+}
+
+// convert applies the conversion formula of the sensor record to a raw
+// reading: y = L((M*x + B*10^Bexp) * 10^Rexp).
+func (s ipmiSensor) convert(raw uint8) float64 {
+	var x float64
+	switch s.analogFormat {
+	case ipmiAnalogFormatOnes:
+		if raw&0x80 != 0 {
+			x = -float64(^raw)
+		} else {
+			x = float64(raw)
+		}
+	case ipmiAnalogFormatTwos:
+		x = float64(int8(raw))
+	default:
+		x = float64(raw)
+	}
+
+	y := (float64(s.m)*x + float64(s.b)*math.Pow10(s.bExp)) * math.Pow10(s.rExp)
+	switch s.linearization {
+	case 1:
+		y = math.Log(y)
+	case 2:
+		y = math.Log10(y)
+	case 3:
+		y = math.Log2(y)
+	case 4:
+		y = math.Exp(y)
+	case 5:
+		y = math.Pow(10, y)
+	case 6:
+		y = math.Exp2(y)
+	case 7:
+		y = 1 / y
+	case 8:
+		y = y * y
+	case 9:
+		y = y * y * y
+	case 10:
+		y = math.Sqrt(y)
+	case 11:
+		y = math.Cbrt(y)
+	}
+	return y
+}
+
+// readIPMISensors returns the analog threshold sensors owned by the BMC
+// from the SDR repository.
+func readIPMISensors(conn ipmiConn) ([]ipmiSensor, error) {
+	reservation, err := reserveIPMISDR(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := []ipmiSensor{}
+	// Some BMCs link the last record back to an earlier one instead of
+	// ending the repository with 0xffff.
+	seen := map[uint16]bool{}
+	for id := uint16(0); id != ipmiSDRLastRecord && !seen[id]; {
+		if len(seen) == ipmiSDRMaxRecords {
+			return nil, fmt.Errorf("SDR repository has more than %d records", ipmiSDRMaxRecords)
+		}
+		seen[id] = true
+		var (
+			next   uint16
+			record []byte
+		)
+		for retry := 0; ; retry++ {
+			next, record, err = readIPMISDR(conn, reservation, id)
+			if !errors.Is(err, errIPMIReservationCanceled) || retry == ipmiMaxReservationRetry {
+				break
+			}
+			if reservation, err = reserveIPMISDR(conn); err != nil {
+				return nil, err
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := parseIPMISensorRecord(record); ok {
+			sensors = append(sensors, s)
+		}
+		id = next
+	}
+	return sensors, nil
+}
+
+// errIPMIReservationCanceled is returned if the SDR repository changed while
+// it was being read.
+var errIPMIReservationCanceled = errors.New("SDR reservation canceled")
+
+func reserveIPMISDR(conn ipmiConn) (uint16, error) {
+	cc, data, err := conn.command(ipmiNetFnStorage, ipmiCmdReserveSDR, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	if cc != ipmiCompletionOK || len(data) < 2 {
+		return 0, fmt.Errorf("reserve SDR repository failed with completion code %#x", cc)
+	}
+	return binary.LittleEndian.Uint16(data), nil
+}
+
+// readIPMISDR returns the id of the next record and the record with the
+// given id. The record is read in chunks as many BMCs can't return a whole
+// record at once.
+func readIPMISDR(conn ipmiConn, reservation, id uint16) (uint16, []byte, error) {
+	var (
+		next   uint16
+		record []byte
+		length = ipmiSDRHeaderLength
+	)
+	for len(record) < length {
+		size := length - len(record)
+		if size > ipmiSDRChunkLength {
+			size = ipmiSDRChunkLength
+		}
+		req := make([]byte, 6)
+		binary.LittleEndian.PutUint16(req[0:], reservation)
+		binary.LittleEndian.PutUint16(req[2:], id)
+		req[4] = uint8(len(record))
+		req[5] = uint8(size)
+
+		cc, data, err := conn.command(ipmiNetFnStorage, ipmiCmdGetSDR, 0, req)
+		if err != nil {
+			return 0, nil, err
+		}
+		if cc == ipmiCompletionReservationCanceled {
+			return 0, nil, errIPMIReservationCanceled
+		}
+		if cc != ipmiCompletionOK || len(data) < 2+size {
+			return 0, nil, fmt.Errorf("get SDR %#x failed with completion code %#x", id, cc)
+		}
+		next = binary.LittleEndian.Uint16(data)
+		record = append(record, data[2:2+size]...)
+		if len(record) == ipmiSDRHeaderLength {
+			length += int(record[4])
+		}
+	}
+	return next, record, nil
+}
+
+// parseIPMISensorRecord returns the sensor of a full sensor record, ok is
+// false for other records and sensors that can't be read from the BMC, see
+// table 43-1 of the IPMI specification for the layout.
+func parseIPMISensorRecord(r []byte) (ipmiSensor, bool) {
+	if len(r) < 48 || r[3] != ipmiSDRTypeFullSensor {
+		return ipmiSensor{}, false
+	}
+	// Sensors of satellite controllers would need bridged requests.
+	if r[5] != ipmiBMCSlaveAddr || r[13] != ipmiEventTypeThreshold {
+		return ipmiSensor{}, false
+	}
+	s := ipmiSensor{
+		id:            binary.LittleEndian.Uint16(r),
+		number:        r[7],
+		lun:           r[6] & 0x03,
+		analogFormat:  r[20] >> 6,
+		unit:          r[21],
+		linearization: r[23] & 0x7f,
+		m:             ipmiSigned(int(r[24])|int(r[25]>>6)<<8, 10),
+		b:             ipmiSigned(int(r[26])|int(r[27]>>6)<<8, 10),
+		rExp:          ipmiSigned(int(r[29]>>4), 4),
+		bExp:          ipmiSigned(int(r[29]&0x0f), 4),
+	}
+	if s.analogFormat == ipmiAnalogFormatNone {
+		return ipmiSensor{}, false
+	}
+
+	// Only 8 bit ASCII names are supported, which is what BMCs use.
+	nameLen := int(r[47] & 0x1f)
+	if 48+nameLen > len(r) {
+		nameLen = len(r) - 48
+	}
+	s.name = strings.TrimSpace(strings.TrimRight(string(r[48:48+nameLen]), "\x00"))
+	if s.name == "" {
+		s.name = fmt.Sprintf("sensor_%d", s.number)
+	}
+	return s, true
+}
+
+// ipmiSigned converts a two's complement number of the given bits.
+func ipmiSigned(v int, bits uint) int {
+	if v&(1<<(bits-1)) != 0 {
+		return v - 1<<bits
+	}
+	return v
+}
+
+// ipmiDevice is an ipmiConn talking to the BMC of the system interface
+// through /dev/ipmi0.
+type ipmiDevice struct {
+	f     *os.File
+	msgid int
+	buf   [1024]byte
+}
+
+func openIPMIDevice() (ipmiConn, error) {
+	f, err := os.OpenFile(rootfsFilePath("/dev/ipmi0"), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ipmiDevice{f: f}, nil
+}
+
+func (d *ipmiDevice) Close() error {
+	return d.f.Close()
+}
+
+func (d *ipmiDevice) command(netfn, cmd, lun uint8, data []byte) (uint8, []byte, error) {
+	addr := ipmiSystemInterfaceAddr{
+		addrType: ipmiSystemInterfaceAddrType,
+		channel:  ipmiBMCChannel,
+		lun:      lun,
+	}
+	d.msgid++
+	req := ipmiReq{
+		addr:    uintptr(unsafe.Pointer(&addr)),
+		addrLen: uint32(unsafe.Sizeof(addr)),
+		msgid:   d.msgid,
+		msg: ipmiMsg{
+			netfn:   netfn,
+			cmd:     cmd,
+			dataLen: uint16(len(data)),
+		},
+	}
+	if len(data) > 0 {
+		req.msg.data = uintptr(unsafe.Pointer(&data[0]))
+	}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), ipmiCtlSendCommand, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(&addr)
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return 0, nil, fmt.Errorf("sending IPMI command failed: %w", errno)
+	}
+
+	// Skip responses to earlier requests that timed out.
+	for {
+		fds := []unix.PollFd{{Fd: int32(d.f.Fd()), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, ipmiTimeoutMs)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return 0, nil, err
+		}
+		if n == 0 {
+			return 0, nil, errors.New("timeout waiting for IPMI response")
+		}
+
+		var respAddr ipmiSystemInterfaceAddr
+		recv := ipmiRecv{
+			addr:    uintptr(unsafe.Pointer(&respAddr)),
+			addrLen: uint32(unsafe.Sizeof(respAddr)),
+			msg: ipmiMsg{
+				dataLen: uint16(len(d.buf)),
+				data:    uintptr(unsafe.Pointer(&d.buf[0])),
+			},
+		}
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), ipmiCtlReceiveMsgTrunc, uintptr(unsafe.Pointer(&recv)))
+		runtime.KeepAlive(&respAddr)
+		if errno != 0 && errno != unix.EMSGSIZE {
+			return 0, nil, fmt.Errorf("receiving IPMI response failed: %w", errno)
+		}
+		if recv.msgid != d.msgid {
+			continue
+		}
+		if recv.msg.dataLen == 0 {
+			return 0, nil, errors.New("empty IPMI response")
+		}
+		resp := make([]byte, recv.msg.dataLen-1)
+		copy(resp, d.buf[1:recv.msg.dataLen])
+		return d.buf[0], resp, nil
+	}
+}
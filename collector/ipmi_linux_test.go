@@ -0,0 +1,211 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noipmi
+
+package collector
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// ipmiFullSensorRecord returns a full sensor record of a threshold sensor
+// owned by the BMC.
+func ipmiFullSensorRecord(id uint16, number, unit uint8, m, rExp int, name string) []byte {
+	r := make([]byte, 48+len(name))
+	binary.LittleEndian.PutUint16(r, id)
+	r[2] = 0x51
+	r[3] = ipmiSDRTypeFullSensor
+	r[4] = uint8(len(r) - ipmiSDRHeaderLength)
+	r[5] = ipmiBMCSlaveAddr
+	r[7] = number
+	r[13] = ipmiEventTypeThreshold
+	r[21] = unit
+	r[24] = uint8(m)
+	r[25] = uint8(m>>8) << 6
+	r[29] = uint8(rExp&0x0f) << 4
+	r[47] = 0xc0 | uint8(len(name))
+	copy(r[48:], name)
+	return r
+}
+
+// fakeBMC answers the SDR and sensor reading commands, the first Get SDR
+// request fails with a canceled reservation. Record ids wrap around the
+// records and next overrides the id of the record following a record.
+type fakeBMC struct {
+	records     [][]byte
+	readings    map[uint8][]byte
+	reservation uint16
+	next        func(id uint16) uint16
+}
+
+func (b *fakeBMC) Close() error { return nil }
+
+func (b *fakeBMC) command(netfn, cmd, lun uint8, data []byte) (uint8, []byte, error) {
+	switch {
+	case netfn == ipmiNetFnStorage && cmd == ipmiCmdReserveSDR:
+		b.reservation++
+		return ipmiCompletionOK, []byte{uint8(b.reservation), 0}, nil
+	case netfn == ipmiNetFnStorage && cmd == ipmiCmdGetSDR:
+		if binary.LittleEndian.Uint16(data) != 2 {
+			return ipmiCompletionReservationCanceled, nil, nil
+		}
+		id := binary.LittleEndian.Uint16(data[2:])
+		i := int(id) % len(b.records)
+		next := uint16(i + 1)
+		if i == len(b.records)-1 {
+			next = ipmiSDRLastRecord
+		}
+		if b.next != nil {
+			next = b.next(id)
+		}
+		offset, size := int(data[4]), int(data[5])
+		resp := make([]byte, 2, 2+size)
+		binary.LittleEndian.PutUint16(resp, next)
+		return ipmiCompletionOK, append(resp, b.records[i][offset:offset+size]...), nil
+	case netfn == ipmiNetFnSensor && cmd == ipmiCmdGetSensorReading:
+		reading, ok := b.readings[data[0]]
+		if !ok {
+			return 0xcb, nil, nil
+		}
+		return ipmiCompletionOK, reading, nil
+	}
+	return 0xc1, nil, nil
+}
+
+func TestIPMICollector(t *testing.T) {
+	satellite := ipmiFullSensorRecord(5, 6, ipmiUnitCelsius, 1, 0, "Satellite Temp")
+	satellite[5] = 0x2c
+	discrete := ipmiFullSensorRecord(6, 7, 0, 1, 0, "PSU Status")
+	discrete[13] = 0x6f
+	bmc := &fakeBMC{
+		records: [][]byte{
+			ipmiFullSensorRecord(0, 1, ipmiUnitCelsius, 1, 0, "CPU Temp"),
+			ipmiFullSensorRecord(1, 2, ipmiUnitRPM, 75, 0, "FAN1"),
+			ipmiFullSensorRecord(2, 3, ipmiUnitVolts, 500, -4, "12V"),
+			ipmiFullSensorRecord(3, 4, ipmiUnitWatts, 2, 0, "PSU1 Power"),
+			ipmiFullSensorRecord(4, 5, ipmiUnitRPM, 75, 0, "FAN2"),
+			satellite,
+			discrete,
+			// Sensor names of BMCs aren't unique.
+			ipmiFullSensorRecord(7, 8, ipmiUnitCelsius, 1, 0, "CPU Temp"),
+		},
+		readings: map[uint8][]byte{
+			1: {45, 0xc0},
+			2: {80, 0xc0},
+			3: {240, 0xc0},
+			4: {100, 0xc0},
+			// FAN2 isn't installed.
+			5: {0, 0xe0},
+			6: {30, 0xc0},
+			7: {1, 0xc0},
+			8: {52, 0xc0},
+		},
+	}
+
+	c, err := NewIPMICollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*ipmiCollector).open = func() (ipmiConn, error) { return bmc, nil }
+
+	expected := `
+# HELP node_ipmi_fan_speed_rpm Reading of an IPMI fan speed sensor.
+# TYPE node_ipmi_fan_speed_rpm gauge
+node_ipmi_fan_speed_rpm{id="1",sensor="FAN1"} 6000
+# HELP node_ipmi_power_watts Reading of an IPMI power sensor.
+# TYPE node_ipmi_power_watts gauge
+node_ipmi_power_watts{id="3",sensor="PSU1 Power"} 200
+# HELP node_ipmi_temperature_celsius Reading of an IPMI temperature sensor.
+# TYPE node_ipmi_temperature_celsius gauge
+node_ipmi_temperature_celsius{id="0",sensor="CPU Temp"} 45
+node_ipmi_temperature_celsius{id="7",sensor="CPU Temp"} 52
+# HELP node_ipmi_voltage_volts Reading of an IPMI voltage sensor.
+# TYPE node_ipmi_voltage_volts gauge
+node_ipmi_voltage_volts{id="2",sensor="12V"} 12
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadIPMISensorsLoop(t *testing.T) {
+	bmc := &fakeBMC{
+		records: [][]byte{
+			ipmiFullSensorRecord(0, 1, ipmiUnitCelsius, 1, 0, "CPU Temp"),
+			ipmiFullSensorRecord(1, 2, ipmiUnitRPM, 75, 0, "FAN1"),
+		},
+		// The last record links back to the first one.
+		next: func(id uint16) uint16 { return (id + 1) % 2 },
+	}
+	sensors, err := readIPMISensors(bmc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sensors) != 2 {
+		t.Errorf("want 2 sensors, got %d", len(sensors))
+	}
+
+	// A repository which never ends with distinct record ids.
+	bmc.next = func(id uint16) uint16 { return id + 1 }
+	if _, err := readIPMISensors(bmc); err == nil {
+		t.Error("want an error for an endless SDR repository")
+	}
+}
+
+func TestIPMISensorConvert(t *testing.T) {
+	for _, tc := range []struct {
+		sensor ipmiSensor
+		raw    uint8
+		want   float64
+	}{
+		{sensor: ipmiSensor{m: 1}, raw: 200, want: 200},
+		{sensor: ipmiSensor{m: 1, analogFormat: ipmiAnalogFormatTwos}, raw: 0xfe, want: -2},
+		{sensor: ipmiSensor{m: 1, analogFormat: ipmiAnalogFormatOnes}, raw: 0xfe, want: -1},
+		{sensor: ipmiSensor{m: 5, b: 3, bExp: 1, rExp: 1}, raw: 10, want: 800},
+		{sensor: ipmiSensor{m: 2, linearization: 8}, raw: 3, want: 36},
+	} {
+		if got := tc.sensor.convert(tc.raw); got != tc.want {
+			t.Errorf("%+v: want %v for %d, got %v", tc.sensor, tc.want, tc.raw, got)
+		}
+	}
+}
+
+func TestIPMISigned(t *testing.T) {
+	if got := ipmiSigned(0x3ff, 10); got != -1 {
+		t.Errorf("want -1, got %d", got)
+	}
+	if got := ipmiSigned(0x1ff, 10); got != 511 {
+		t.Errorf("want 511, got %d", got)
+	}
+	if got := ipmiSigned(0xc, 4); got != -4 {
+		t.Errorf("want -4, got %d", got)
+	}
+}
+
+func TestIPMICollectorNoDevice(t *testing.T) {
+	c, err := NewIPMICollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*ipmiCollector).open = func() (ipmiConn, error) { return nil, os.ErrNotExist }
+	if err := c.Update(nil); err != ErrNoData {
+		t.Errorf("want ErrNoData, got %v", err)
+	}
+}
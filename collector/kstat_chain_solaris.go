@@ -0,0 +1,39 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build solaris
+
+package collector
+
+// #cgo LDFLAGS: -lkstat
+// #include <kstat.h>
+import "C"
+
+import (
+	"errors"
+)
+
+// kstatChainID returns the ID of the kstat chain, which the kernel changes
+// whenever a kstat is added or removed. go-kstat doesn't expose the chain ID
+// of its handle, so a handle of its own is opened for it.
+func kstatChainID() (int64, error) {
+	kc, err := C.kstat_open()
+	if kc == nil {
+		if err == nil {
+			err = errors.New("kstat_open failed")
+		}
+		return 0, err
+	}
+	defer C.kstat_close(kc)
+	return int64(kc.kc_chain_id), nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build solaris
+// +build !nokstat
+
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/siebenmann/go-kstat"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	kstatModules            = kingpin.Flag("collector.kstat.modules", "Comma separated list of kstat modules to export all named statistics from.").Default("").String()
+	kstatInvalidMetricChars = regexp.MustCompile("[^a-z0-9_]")
+)
+
+type kstatCollector struct {
+	modules []string
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector("kstat", defaultDisabled, NewKstatCollector)
+}
+
+// NewKstatCollector returns a new Collector exposing the named statistics of
+// arbitrary kstat modules.
+func NewKstatCollector(logger log.Logger) (Collector, error) {
+	var modules []string
+	for _, module := range strings.Split(*kstatModules, ",") {
+		module = strings.TrimSpace(module)
+		if module != "" {
+			modules = append(modules, module)
+		}
+	}
+	return &kstatCollector{
+		modules: modules,
+		logger:  logger,
+	}, nil
+}
+
+func kstatMetricName(name string) string {
+	return strings.Trim(kstatInvalidMetricChars.ReplaceAllLiteralString(strings.ToLower(name), "_"), "_")
+}
+
+func (c *kstatCollector) Update(ch chan<- prometheus.Metric) error {
+	if len(c.modules) == 0 {
+		return ErrNoData
+	}
+
+	tok, err := kstat.Open()
+	if err != nil {
+		return err
+	}
+
+	defer tok.Close()
+
+	wanted := make(map[string]bool, len(c.modules))
+	for _, module := range c.modules {
+		wanted[module] = true
+	}
+
+	// Names that only differ in invalid characters sanitize to the same
+	// metric name, so remember which statistic claimed each name first.
+	seen := map[string]string{}
+	for _, ks := range tok.All() {
+		if !wanted[ks.Module] || ks.Type != kstat.NamedStat {
+			continue
+		}
+
+		named, err := ks.AllNamed()
+		if err != nil {
+			// The kstat may have been removed since the chain was read.
+			level.Debug(c.logger).Log("msg", "Failed to read kstat", "kstat", ks, "err", err)
+			continue
+		}
+		c.updateNamed(ch, ks, named, seen)
+	}
+
+	return nil
+}
+
+func (c *kstatCollector) updateNamed(ch chan<- prometheus.Metric, ks *kstat.KStat, named []*kstat.Named, seen map[string]string) {
+	for _, n := range named {
+		var value float64
+		switch n.Type {
+		case kstat.Uint32, kstat.Uint64:
+			value = float64(n.UintVal)
+		case kstat.Int32, kstat.Int64:
+			value = float64(n.IntVal)
+		default:
+			continue
+		}
+
+		stat := ks.Module + ":" + n.Name
+		metricName := prometheus.BuildFQName(namespace, "kstat", kstatMetricName(ks.Module)+"_"+kstatMetricName(n.Name))
+		if first, ok := seen[metricName]; ok && first != stat {
+			level.Debug(c.logger).Log("msg", "Skipping kstat statistic with colliding metric name", "statistic", stat, "collides_with", first, "metric", metricName)
+			continue
+		}
+		seen[metricName] = stat
+
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				metricName,
+				fmt.Sprintf("kstat statistic %s:*:*:%s.", ks.Module, n.Name),
+				[]string{"instance", "name"}, nil,
+			),
+			prometheus.UntypedValue,
+			value,
+			strconv.Itoa(ks.Instance), ks.Name,
+		)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build solaris
+// +build !nokstat
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/siebenmann/go-kstat"
+)
+
+func TestKstatMetricName(t *testing.T) {
+	for in, want := range map[string]string{
+		"rbytes64":        "rbytes64",
+		"crtime":          "crtime",
+		"ipackets":        "ipackets",
+		"mac_misc_stat":   "mac_misc_stat",
+		"RX-Errors":       "rx_errors",
+		"l2_hdr.size":     "l2_hdr_size",
+		"-leading/trail-": "leading_trail",
+		"top%":            "top",
+	} {
+		if got := kstatMetricName(in); got != want {
+			t.Errorf("kstatMetricName(%q): want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestKstatNamedCollisions(t *testing.T) {
+	c := &kstatCollector{logger: log.NewNopLogger()}
+	seen := map[string]string{}
+	ch := make(chan prometheus.Metric, 10)
+
+	for i, named := range [][]*kstat.Named{
+		{
+			{Name: "rx-errors", Type: kstat.Uint64, UintVal: 1},
+			{Name: "rx_errors", Type: kstat.Uint64, UintVal: 2},
+			{Name: "link_state", Type: kstat.String},
+		},
+		{
+			{Name: "rx-errors", Type: kstat.Uint64, UintVal: 3},
+		},
+	} {
+		c.updateNamed(ch, &kstat.KStat{Module: "link", Instance: i, Name: "net0"}, named, seen)
+	}
+	close(ch)
+
+	var got []float64
+	for m := range ch {
+		if m.Desc().String() != prometheus.NewDesc("node_kstat_link_rx_errors", "kstat statistic link:*:*:rx-errors.", []string{"instance", "name"}, nil).String() {
+			t.Errorf("unexpected metric %s", m.Desc())
+		}
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, pb.GetUntyped().GetValue())
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("want values [1 3] of rx-errors, got %v", got)
+	}
+}
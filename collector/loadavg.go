@@ -40,6 +40,10 @@ func NewLoadavgCollector(logger log.Logger) (Collector, error) {
 			{prometheus.NewDesc(namespace+"_load1", "1m load average.", nil, nil), prometheus.GaugeValue},
 			{prometheus.NewDesc(namespace+"_load5", "5m load average.", nil, nil), prometheus.GaugeValue},
 			{prometheus.NewDesc(namespace+"_load15", "15m load average.", nil, nil), prometheus.GaugeValue},
+			// Only available on Linux.
+			{prometheus.NewDesc(namespace+"_load_runnable_entities", "Number of currently runnable kernel scheduling entities (processes, threads).", nil, nil), prometheus.GaugeValue},
+			{prometheus.NewDesc(namespace+"_load_entities", "Number of kernel scheduling entities that currently exist.", nil, nil), prometheus.GaugeValue},
+			{prometheus.NewDesc(namespace+"_last_pid", "PID of the most recently created process.", nil, nil), prometheus.GaugeValue},
 		},
 		logger: logger,
 	}, nil
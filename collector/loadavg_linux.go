@@ -35,9 +35,10 @@ func getLoad() (loads []float64, err error) {
 	return loads, nil
 }
 
-// Parse /proc loadavg and return 1m, 5m and 15m.
+// Parse /proc loadavg and return 1m, 5m and 15m, followed by the number of
+// runnable and existing scheduling entities and the last pid if present.
 func parseLoad(data string) (loads []float64, err error) {
-	loads = make([]float64, 3)
+	loads = make([]float64, 3, 6)
 	parts := strings.Fields(data)
 	if len(parts) < 3 {
 		return nil, fmt.Errorf("unexpected content in %s", procFilePath("loadavg"))
@@ -48,5 +49,20 @@ func parseLoad(data string) (loads []float64, err error) {
 			return nil, fmt.Errorf("could not parse load '%s': %w", load, err)
 		}
 	}
+	if len(parts) < 5 {
+		return loads, nil
+	}
+
+	entities := strings.Split(parts[3], "/")
+	if len(entities) != 2 {
+		return nil, fmt.Errorf("could not parse scheduling entities '%s'", parts[3])
+	}
+	for _, field := range append(entities, parts[4]) {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse '%s' in %s: %w", field, procFilePath("loadavg"), err)
+		}
+		loads = append(loads, v)
+	}
 	return loads, nil
 }
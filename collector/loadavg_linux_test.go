@@ -16,15 +16,32 @@ package collector
 import "testing"
 
 func TestLoad(t *testing.T) {
-	want := []float64{0.21, 0.37, 0.39}
+	want := []float64{0.21, 0.37, 0.39, 1, 719, 19737}
 	loads, err := parseLoad("0.21 0.37 0.39 1/719 19737")
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if len(loads) != len(want) {
+		t.Fatalf("want %d values, got %d", len(want), len(loads))
+	}
 	for i, load := range loads {
 		if want[i] != load {
 			t.Fatalf("want load %f, got %f", want[i], load)
 		}
 	}
 }
+
+func TestLoadFormats(t *testing.T) {
+	if loads, err := parseLoad("0.21 0.37 0.39"); err != nil || len(loads) != 3 {
+		t.Errorf("want only the load averages, got %v, %v", loads, err)
+	}
+
+	if loads, err := parseLoad("0.21 0.37 0.39 1/719 19737 42"); err != nil || len(loads) != 6 {
+		t.Errorf("want additional fields to be ignored, got %v, %v", loads, err)
+	}
+
+	if _, err := parseLoad("0.21 0.37 0.39 1-719 19737"); err == nil {
+		t.Error("expected an error for malformed scheduling entities")
+	}
+}
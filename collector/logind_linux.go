@@ -16,11 +16,14 @@
 package collector
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"syscall"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -29,6 +32,8 @@ const (
 	logindSubsystem = "logind"
 	dbusObject      = "org.freedesktop.login1"
 	dbusPath        = "/org/freedesktop/login1"
+
+	dbusErrorServiceUnknown = "org.freedesktop.DBus.Error.ServiceUnknown"
 )
 
 var (
@@ -37,15 +42,11 @@ var (
 	attrRemoteValues = []string{"true", "false"}
 	attrTypeValues   = []string{"other", "unspecified", "tty", "x11", "wayland", "mir", "web"}
 	attrClassValues  = []string{"other", "user", "greeter", "lock-screen", "background"}
-
-	sessionsDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, logindSubsystem, "sessions"),
-		"Number of sessions registered in logind.", []string{"seat", "remote", "type", "class"}, nil,
-	)
 )
 
 type logindCollector struct {
-	logger log.Logger
+	sessionsDesc *prometheus.Desc
+	logger       log.Logger
 }
 
 type logindDbus struct {
@@ -86,20 +87,45 @@ func init() {
 
 // NewLogindCollector returns a new Collector exposing logind statistics.
 func NewLogindCollector(logger log.Logger) (Collector, error) {
-	return &logindCollector{logger}, nil
+	return &logindCollector{
+		sessionsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, logindSubsystem, "sessions"),
+			"Number of sessions registered in logind.", []string{"seat", "remote", "type", "class"}, nil,
+		),
+		logger: logger,
+	}, nil
 }
 
 func (lc *logindCollector) Update(ch chan<- prometheus.Metric) error {
 	c, err := newDbus()
 	if err != nil {
+		if logindUnavailable(err) {
+			level.Debug(lc.logger).Log("msg", "dbus is not available, skipping", "err", err)
+			return ErrNoData
+		}
 		return fmt.Errorf("unable to connect to dbus: %w", err)
 	}
 	defer c.conn.Close()
 
-	return collectMetrics(ch, c)
+	err = collectMetrics(ch, c, lc.sessionsDesc)
+	if err != nil && logindUnavailable(err) {
+		level.Debug(lc.logger).Log("msg", "logind is not available, skipping", "err", err)
+		return ErrNoData
+	}
+	return err
+}
+
+// logindUnavailable returns whether err is caused by a missing system bus or
+// logind not running, like in containers.
+func logindUnavailable(err error) bool {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		return dbusErr.Name == dbusErrorServiceUnknown
+	}
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED)
 }
 
-func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
+func collectMetrics(ch chan<- prometheus.Metric, c logindInterface, sessionsDesc *prometheus.Desc) error {
 	seats, err := c.listSeats()
 	if err != nil {
 		return fmt.Errorf("unable to get seats: %w", err)
@@ -14,8 +14,14 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
 	"testing"
 
+	"github.com/go-kit/kit/log"
 	"github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -84,9 +90,13 @@ func TestLogindCollectorKnownStringOrOther(t *testing.T) {
 }
 
 func TestLogindCollectorCollectMetrics(t *testing.T) {
+	lc, err := NewLogindCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
 	ch := make(chan prometheus.Metric)
 	go func() {
-		collectMetrics(ch, &testLogindInterface{})
+		collectMetrics(ch, &testLogindInterface{}, lc.(*logindCollector).sessionsDesc)
 		close(ch)
 	}()
 
@@ -100,3 +110,19 @@ func TestLogindCollectorCollectMetrics(t *testing.T) {
 		t.Errorf("collectMetrics did not generate the expected number of metrics: got %d, expected %d.", count, expected)
 	}
 }
+
+func TestLogindUnavailable(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: fmt.Errorf("unable to get seats: %w", dbus.Error{Name: dbusErrorServiceUnknown}), want: true},
+		{err: fmt.Errorf("unable to get seats: %w", dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}), want: false},
+		{err: &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.ENOENT)}, want: true},
+		{err: errors.New("unexpected reply"), want: false},
+	} {
+		if got := logindUnavailable(tc.err); got != tc.want {
+			t.Errorf("logindUnavailable(%v): got %v, expected %v.", tc.err, got, tc.want)
+		}
+	}
+}
@@ -16,9 +16,13 @@
 package collector
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -26,8 +30,32 @@ import (
 	"github.com/prometheus/procfs"
 )
 
+var (
+	mdDeviceLineRE   = regexp.MustCompile(`^(md\S+)\s*:`)
+	mdProgressLineRE = regexp.MustCompile(`(?:recovery|resync|reshape|check)\s*=\s*([0-9.]+)%`)
+	mdSpeedRE        = regexp.MustCompile(`speed=([0-9]+)K/sec`)
+)
+
+// mdProgress is the progress of a running recovery, resync, reshape or check of
+// an md device.
+type mdProgress struct {
+	percent float64
+	// speed is in bytes per second, zero if it is not reported.
+	speed float64
+}
+
 type mdadmCollector struct {
-	logger log.Logger
+	activeDesc          *prometheus.Desc
+	inActiveDesc        *prometheus.Desc
+	recoveringDesc      *prometheus.Desc
+	resyncDesc          *prometheus.Desc
+	disksDesc           *prometheus.Desc
+	disksTotalDesc      *prometheus.Desc
+	blocksTotalDesc     *prometheus.Desc
+	blocksSyncedDesc    *prometheus.Desc
+	recoveryPercentDesc *prometheus.Desc
+	recoverySpeedDesc   *prometheus.Desc
+	logger              log.Logger
 }
 
 func init() {
@@ -36,63 +64,76 @@ func init() {
 
 // NewMdadmCollector returns a new Collector exposing raid statistics.
 func NewMdadmCollector(logger log.Logger) (Collector, error) {
-	return &mdadmCollector{logger}, nil
-}
+	return &mdadmCollector{
+		activeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "state"),
+			"Indicates the state of md-device.",
+			[]string{"device"},
+			prometheus.Labels{"state": "active"},
+		),
+		inActiveDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "state"),
+			"Indicates the state of md-device.",
+			[]string{"device"},
+			prometheus.Labels{"state": "inactive"},
+		),
+		recoveringDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "state"),
+			"Indicates the state of md-device.",
+			[]string{"device"},
+			prometheus.Labels{"state": "recovering"},
+		),
+		resyncDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "state"),
+			"Indicates the state of md-device.",
+			[]string{"device"},
+			prometheus.Labels{"state": "resync"},
+		),
 
-var (
-	activeDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "state"),
-		"Indicates the state of md-device.",
-		[]string{"device"},
-		prometheus.Labels{"state": "active"},
-	)
-	inActiveDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "state"),
-		"Indicates the state of md-device.",
-		[]string{"device"},
-		prometheus.Labels{"state": "inactive"},
-	)
-	recoveringDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "state"),
-		"Indicates the state of md-device.",
-		[]string{"device"},
-		prometheus.Labels{"state": "recovering"},
-	)
-	resyncDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "state"),
-		"Indicates the state of md-device.",
-		[]string{"device"},
-		prometheus.Labels{"state": "resync"},
-	)
+		disksDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "disks"),
+			"Number of active/failed/spare disks of device.",
+			[]string{"device", "state"},
+			nil,
+		),
 
-	disksDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "disks"),
-		"Number of active/failed/spare disks of device.",
-		[]string{"device", "state"},
-		nil,
-	)
+		disksTotalDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "disks_required"),
+			"Total number of disks of device.",
+			[]string{"device"},
+			nil,
+		),
 
-	disksTotalDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "disks_required"),
-		"Total number of disks of device.",
-		[]string{"device"},
-		nil,
-	)
+		blocksTotalDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "blocks"),
+			"Total number of blocks on device.",
+			[]string{"device"},
+			nil,
+		),
 
-	blocksTotalDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "blocks"),
-		"Total number of blocks on device.",
-		[]string{"device"},
-		nil,
-	)
+		blocksSyncedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "blocks_synced"),
+			"Number of blocks synced on device.",
+			[]string{"device"},
+			nil,
+		),
 
-	blocksSyncedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "md", "blocks_synced"),
-		"Number of blocks synced on device.",
-		[]string{"device"},
-		nil,
-	)
-)
+		recoveryPercentDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "recovery_percent"),
+			"Progress of the running recovery, resync, reshape or check of device.",
+			[]string{"device"},
+			nil,
+		),
+
+		recoverySpeedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "md", "recovery_speed_bytes"),
+			"Speed of the running recovery, resync, reshape or check of device in bytes per second.",
+			[]string{"device"},
+			nil,
+		),
+		logger: logger,
+	}, nil
+}
 
 func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) error {
 	fs, err := procfs.NewFS(*procPath)
@@ -112,6 +153,11 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) error {
 		return fmt.Errorf("error parsing mdstatus: %w", err)
 	}
 
+	progress, err := parseMDStatProgress(procFilePath("mdstat"))
+	if err != nil {
+		return fmt.Errorf("error parsing mdstat progress: %w", err)
+	}
+
 	for _, mdStat := range mdStats {
 		level.Debug(c.logger).Log("msg", "collecting metrics for device", "device", mdStat.Name)
 
@@ -119,74 +165,133 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) error {
 		stateVals[mdStat.ActivityState] = 1
 
 		ch <- prometheus.MustNewConstMetric(
-			disksTotalDesc,
+			c.disksTotalDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.DisksTotal),
 			mdStat.Name,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			disksDesc,
+			c.disksDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.DisksActive),
 			mdStat.Name,
 			"active",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			disksDesc,
+			c.disksDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.DisksFailed),
 			mdStat.Name,
 			"failed",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			disksDesc,
+			c.disksDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.DisksSpare),
 			mdStat.Name,
 			"spare",
 		)
 		ch <- prometheus.MustNewConstMetric(
-			activeDesc,
+			c.activeDesc,
 			prometheus.GaugeValue,
 			stateVals["active"],
 			mdStat.Name,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			inActiveDesc,
+			c.inActiveDesc,
 			prometheus.GaugeValue,
 			stateVals["inactive"],
 			mdStat.Name,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			recoveringDesc,
+			c.recoveringDesc,
 			prometheus.GaugeValue,
 			stateVals["recovering"],
 			mdStat.Name,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			resyncDesc,
+			c.resyncDesc,
 			prometheus.GaugeValue,
 			stateVals["resyncing"],
 			mdStat.Name,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			blocksTotalDesc,
+			c.blocksTotalDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.BlocksTotal),
 			mdStat.Name,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			blocksSyncedDesc,
+			c.blocksSyncedDesc,
 			prometheus.GaugeValue,
 			float64(mdStat.BlocksSynced),
 			mdStat.Name,
 		)
+
+		if p, ok := progress[mdStat.Name]; ok {
+			ch <- prometheus.MustNewConstMetric(
+				c.recoveryPercentDesc,
+				prometheus.GaugeValue,
+				p.percent,
+				mdStat.Name,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				c.recoverySpeedDesc,
+				prometheus.GaugeValue,
+				p.speed,
+				mdStat.Name,
+			)
+		}
 	}
 
 	return nil
 }
+
+// parseMDStatProgress returns the progress of the md devices with a progress
+// line like "[=>....]  recovery =  8.5% (...) finish=17.0min speed=259783K/sec".
+// Devices without one are not included.
+func parseMDStatProgress(file string) (map[string]mdProgress, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		progress = map[string]mdProgress{}
+		device   string
+		scanner  = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := mdDeviceLineRE.FindStringSubmatch(line); m != nil {
+			device = m[1]
+			continue
+		}
+		if device == "" || !strings.HasPrefix(strings.TrimSpace(line), "[") {
+			continue
+		}
+		m := mdProgressLineRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var p mdProgress
+		if p.percent, err = strconv.ParseFloat(m[1], 64); err != nil {
+			return nil, fmt.Errorf("invalid progress of %s: %w", device, err)
+		}
+		if m := mdSpeedRE.FindStringSubmatch(line); m != nil {
+			speed, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid speed of %s: %w", device, err)
+			}
+			p.speed = speed * 1024
+		}
+		progress[device] = p
+	}
+	return progress, scanner.Err()
+}
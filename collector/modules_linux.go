@@ -0,0 +1,146 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomodules
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const moduleSubsystem = "module"
+
+var (
+	modulesInclude = kingpin.Flag("collector.modules.include", "Regexp of kernel modules to include in the modules collector.").Default(".*").String()
+)
+
+// kernelModule is a line of /proc/modules.
+type kernelModule struct {
+	name     string
+	size     float64
+	refcount float64
+	// hasRefcount is false on kernels without module unloading support,
+	// which show "-" as the reference count.
+	hasRefcount bool
+	state       string
+}
+
+type modulesCollector struct {
+	namePattern *regexp.Regexp
+	loaded      typedDesc
+	refcount    typedDesc
+	size        typedDesc
+	logger      log.Logger
+}
+
+func init() {
+	registerCollector("modules", defaultDisabled, NewModulesCollector)
+}
+
+// NewModulesCollector returns a new Collector exposing the kernel modules
+// from /proc/modules matching --collector.modules.include.
+func NewModulesCollector(logger log.Logger) (Collector, error) {
+	return &modulesCollector{
+		namePattern: regexp.MustCompile(*modulesInclude),
+		loaded: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, moduleSubsystem, "loaded"),
+			"Whether the kernel module is loaded, modules that are being loaded or unloaded are 0.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		refcount: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, moduleSubsystem, "refcount"),
+			"Number of references to the kernel module.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		size: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, moduleSubsystem, "size_bytes"),
+			"Memory size of the kernel module.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *modulesCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("modules"))
+	if err != nil {
+		// The kernel may be built without loadable module support.
+		if os.IsNotExist(err) {
+			level.Debug(c.logger).Log("msg", "No kernel modules found, skipping")
+			return ErrNoData
+		}
+		return err
+	}
+	defer file.Close()
+
+	modules, err := parseModules(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse modules: %w", err)
+	}
+	for _, m := range modules {
+		if !c.namePattern.MatchString(m.name) {
+			continue
+		}
+		loaded := 0.0
+		if m.state == "Live" {
+			loaded = 1
+		}
+		ch <- c.loaded.mustNewConstMetric(loaded, m.name)
+		ch <- c.size.mustNewConstMetric(m.size, m.name)
+		if m.hasRefcount {
+			ch <- c.refcount.mustNewConstMetric(m.refcount, m.name)
+		}
+	}
+	return nil
+}
+
+// parseModules parses the lines of /proc/modules, like:
+//
+//	nf_conntrack 139264 3 nf_nat,xt_conntrack, Live 0x0000000000000000
+func parseModules(r io.Reader) ([]kernelModule, error) {
+	var (
+		modules []kernelModule
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("invalid line in modules: %q", scanner.Text())
+		}
+		size, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %q in modules: %w", fields[1], err)
+		}
+		m := kernelModule{name: fields[0], size: size, state: fields[4]}
+		if fields[2] != "-" {
+			if m.refcount, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				return nil, fmt.Errorf("invalid reference count %q in modules: %w", fields[2], err)
+			}
+			m.hasRefcount = true
+		}
+		modules = append(modules, m)
+	}
+	return modules, scanner.Err()
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomodules
+
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseModules(t *testing.T) {
+	const modules = `nf_conntrack 139264 3 nf_nat,xt_conntrack, Live 0x0000000000000000
+ext4 741376 - - Live 0x0000000000000000
+wireguard 81920 0 - Unloading 0x0000000000000000
+`
+	got, err := parseModules(strings.NewReader(modules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kernelModule{
+		{name: "nf_conntrack", size: 139264, refcount: 3, hasRefcount: true, state: "Live"},
+		{name: "ext4", size: 741376, state: "Live"},
+		{name: "wireguard", size: 81920, hasRefcount: true, state: "Unloading"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -52,6 +52,7 @@ type mountStatsCollector struct {
 	NFSOperationsQueueTimeSecondsTotal    *prometheus.Desc
 	NFSOperationsResponseTimeSecondsTotal *prometheus.Desc
 	NFSOperationsRequestTimeSecondsTotal  *prometheus.Desc
+	NFSOperationsRPCDurationSeconds       *prometheus.Desc
 
 	// Transport statistics
 	NFSTransportBindTotal              *prometheus.Desc
@@ -321,6 +322,13 @@ func NewMountStatsCollector(logger log.Logger) (Collector, error) {
 			nil,
 		),
 
+		NFSOperationsRPCDurationSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "rpc_duration_seconds"),
+			"Summary of the time requests took from when they were enqueued to when they were completely handled for a given operation, in seconds.",
+			opLabels,
+			nil,
+		),
+
 		NFSEventInodeRevalidateTotal: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, subsystem, "event_inode_revalidate_total"),
 			"Number of times cached inode attributes are re-validated from the server.",
@@ -744,6 +752,14 @@ func (c *mountStatsCollector) updateNFSStats(ch chan<- prometheus.Metric, s *pro
 			float64(op.CumulativeTotalRequestMilliseconds%float64Mantissa)/1000.0,
 			opLabelValues...,
 		)
+
+		ch <- prometheus.MustNewConstSummary(
+			c.NFSOperationsRPCDurationSeconds,
+			op.Requests,
+			float64(op.CumulativeTotalRequestMilliseconds%float64Mantissa)/1000.0,
+			nil,
+			opLabelValues...,
+		)
 	}
 
 	ch <- prometheus.MustNewConstMetric(
@@ -33,12 +33,14 @@ var (
 	oldNetdevDeviceInclude = kingpin.Flag("collector.netdev.device-whitelist", "DEPRECATED: Use collector.netdev.device-include").Hidden().String()
 	netdevDeviceExclude    = kingpin.Flag("collector.netdev.device-exclude", "Regexp of net devices to exclude (mutually exclusive to device-include).").String()
 	oldNetdevDeviceExclude = kingpin.Flag("collector.netdev.device-blacklist", "DEPRECATED: Use collector.netdev.device-exclude").Hidden().String()
+	netdevLabelType        = kingpin.Flag("collector.netdev.label-type", "Add a kind label with physical, virtual, bridge, bond or unknown to the net devices.").Default("false").Bool()
 )
 
 type netDevCollector struct {
 	subsystem            string
 	deviceExcludePattern *regexp.Regexp
 	deviceIncludePattern *regexp.Regexp
+	labelType            bool
 	metricDescs          map[string]*prometheus.Desc
 	logger               log.Logger
 }
@@ -87,6 +89,7 @@ func NewNetDevCollector(logger log.Logger) (Collector, error) {
 		subsystem:            "network",
 		deviceExcludePattern: excludePattern,
 		deviceIncludePattern: includePattern,
+		labelType:            *netdevLabelType,
 		metricDescs:          map[string]*prometheus.Desc{},
 		logger:               logger,
 	}, nil
@@ -97,14 +100,22 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) error {
 	if err != nil {
 		return fmt.Errorf("couldn't get netstats: %w", err)
 	}
+	labelNames := []string{"device"}
+	if c.labelType {
+		labelNames = append(labelNames, "kind")
+	}
 	for dev, devStats := range netDev {
+		labelValues := []string{dev}
+		if c.labelType {
+			labelValues = append(labelValues, netDevKind(dev))
+		}
 		for key, value := range devStats {
 			desc, ok := c.metricDescs[key]
 			if !ok {
 				desc = prometheus.NewDesc(
 					prometheus.BuildFQName(namespace, c.subsystem, key+"_total"),
 					fmt.Sprintf("Network device statistic %s.", key),
-					[]string{"device"},
+					labelNames,
 					nil,
 				)
 				c.metricDescs[key] = desc
@@ -113,7 +124,7 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) error {
 			if err != nil {
 				return fmt.Errorf("invalid value %s in netstats: %w", value, err)
 			}
-			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, dev)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v, labelValues...)
 		}
 	}
 	return nil
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -90,3 +91,23 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp, accept *regexp.Regexp,
 	}
 	return netDev, scanner.Err()
 }
+
+// netDevKind classifies a net device by its entry in /sys/class/net. Devices
+// without a hardware device behind them are virtual, unless they are a bridge
+// or bond.
+func netDevKind(device string) string {
+	path := sysFilePath(filepath.Join("class/net", device))
+	if _, err := os.Stat(filepath.Join(path, "type")); err != nil {
+		return "unknown"
+	}
+	for _, k := range []struct{ entry, kind string }{
+		{"bridge", "bridge"},
+		{"bonding", "bond"},
+		{"device", "physical"},
+	} {
+		if _, err := os.Stat(filepath.Join(path, k.entry)); err == nil {
+			return k.kind
+		}
+	}
+	return "virtual"
+}
@@ -18,6 +18,8 @@ import (
 	"os"
 	"regexp"
 	"testing"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 func TestNetDevStatsIgnore(t *testing.T) {
@@ -80,3 +82,20 @@ func TestNetDevStatsAccept(t *testing.T) {
 		t.Error("want fixture interface 💩0 to exist, but it does not")
 	}
 }
+
+func TestNetDevKind(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.sysfs", "fixtures/sys"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for device, want := range map[string]string{
+		"eth0":    "physical",
+		"bond0":   "bond",
+		"dmz":     "bond",
+		"missing": "unknown",
+	} {
+		if got := netDevKind(device); got != want {
+			t.Errorf("%s: want kind %q, got %q", device, want, got)
+		}
+	}
+}
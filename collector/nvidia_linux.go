@@ -0,0 +1,160 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build nvidia,cgo
+// +build !nonvidia
+
+package collector
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const gpuSubsystem = "gpu"
+
+// nvmlLibrary gets information about GPUs, usually by calling NVML.
+type nvmlLibrary interface {
+	// Init loads and initializes the library, it is called until it
+	// succeeds once.
+	Init() error
+	DeviceCount() (int, error)
+	Device(index int) (nvmlDevice, error)
+}
+
+// nvmlDevice gets information about a single GPU.
+type nvmlDevice interface {
+	UUID() (string, error)
+	Utilization() (nvmlUtilization, error)
+	Memory() (nvmlMemory, error)
+	// Temperature returns the die temperature in degrees celsius.
+	Temperature() (uint32, error)
+	// PowerUsage returns the power draw in milliwatts.
+	PowerUsage() (uint32, error)
+}
+
+// nvmlUtilization is the nvmlUtilization_t of a GPU, in percent.
+type nvmlUtilization struct {
+	gpu, memory uint32
+}
+
+// nvmlMemory is the nvmlMemory_t of a GPU, in bytes.
+type nvmlMemory struct {
+	total, free, used uint64
+}
+
+type nvidiaCollector struct {
+	nvml nvmlLibrary
+
+	// mtx guards the loading of NVML, which happens on the first scrape
+	// finding the driver and is kept for later scrapes.
+	mtx    sync.Mutex
+	loaded bool
+
+	utilization, memoryUsed, temperature, power typedDesc
+	logger                                      log.Logger
+}
+
+func init() {
+	registerCollector("nvidia", defaultDisabled, NewNvidiaCollector)
+}
+
+// NewNvidiaCollector returns a new Collector exposing the utilization of
+// NVIDIA GPUs through NVML.
+func NewNvidiaCollector(logger log.Logger) (Collector, error) {
+	return newNvidiaCollector(newNVML(), logger), nil
+}
+
+func newNvidiaCollector(nvml nvmlLibrary, logger log.Logger) *nvidiaCollector {
+	labels := []string{"uuid", "index"}
+	return &nvidiaCollector{
+		nvml: nvml,
+		utilization: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, gpuSubsystem, "utilization_ratio"),
+			"Fraction of the last sample period a kernel was running on the GPU.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		memoryUsed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, gpuSubsystem, "memory_used_bytes"),
+			"Allocated memory of the GPU.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, gpuSubsystem, "temperature_celsius"),
+			"Temperature of the GPU die.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		power: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, gpuSubsystem, "power_watts"),
+			"Power draw of the GPU and its memory.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}
+}
+
+func (c *nvidiaCollector) Update(ch chan<- prometheus.Metric) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.loaded {
+		if err := c.nvml.Init(); err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't load NVML, skipping", "err", err)
+			return ErrNoData
+		}
+		c.loaded = true
+	}
+
+	count, err := c.nvml.DeviceCount()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		device, err := c.nvml.Device(i)
+		if err != nil {
+			return err
+		}
+		uuid, err := device.UUID()
+		if err != nil {
+			return err
+		}
+		labels := []string{uuid, strconv.Itoa(i)}
+
+		// Not all GPUs support all queries, those are skipped.
+		if utilization, err := device.Utilization(); err == nil {
+			ch <- c.utilization.mustNewConstMetric(float64(utilization.gpu)/100, labels...)
+		} else {
+			level.Debug(c.logger).Log("msg", "Couldn't get GPU utilization", "index", i, "err", err)
+		}
+		if memory, err := device.Memory(); err == nil {
+			ch <- c.memoryUsed.mustNewConstMetric(float64(memory.used), labels...)
+		} else {
+			level.Debug(c.logger).Log("msg", "Couldn't get GPU memory", "index", i, "err", err)
+		}
+		if temperature, err := device.Temperature(); err == nil {
+			ch <- c.temperature.mustNewConstMetric(float64(temperature), labels...)
+		} else {
+			level.Debug(c.logger).Log("msg", "Couldn't get GPU temperature", "index", i, "err", err)
+		}
+		if power, err := device.PowerUsage(); err == nil {
+			ch <- c.power.mustNewConstMetric(float64(power)/1000, labels...)
+		} else {
+			level.Debug(c.logger).Log("msg", "Couldn't get GPU power usage", "index", i, "err", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build nvidia,cgo
+// +build !nonvidia
+
+package collector
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var errNVMLNotSupported = errors.New("not supported")
+
+// fakeNVML has two GPUs, the second one doesn't report its power draw.
+type fakeNVML struct {
+	inits int
+}
+
+func (f *fakeNVML) Init() error {
+	f.inits++
+	return nil
+}
+
+func (f *fakeNVML) DeviceCount() (int, error) { return 2, nil }
+
+func (f *fakeNVML) Device(index int) (nvmlDevice, error) {
+	return fakeNVMLDevice(index), nil
+}
+
+type fakeNVMLDevice int
+
+func (d fakeNVMLDevice) UUID() (string, error) {
+	return []string{"GPU-6a1b-0", "GPU-6a1b-1"}[d], nil
+}
+
+func (d fakeNVMLDevice) Utilization() (nvmlUtilization, error) {
+	return nvmlUtilization{gpu: 42 + uint32(d), memory: 10}, nil
+}
+
+func (d fakeNVMLDevice) Memory() (nvmlMemory, error) {
+	return nvmlMemory{total: 16 << 30, free: 12 << 30, used: 4 << 30}, nil
+}
+
+func (d fakeNVMLDevice) Temperature() (uint32, error) {
+	return 65, nil
+}
+
+func (d fakeNVMLDevice) PowerUsage() (uint32, error) {
+	if d == 1 {
+		return 0, errNVMLNotSupported
+	}
+	return 123456, nil
+}
+
+// missingNVML fails to load like on a host without the driver.
+type missingNVML struct{ fakeNVML }
+
+func (*missingNVML) Init() error { return errors.New("libnvidia-ml.so.1 not found") }
+
+func TestNvidiaCollector(t *testing.T) {
+	nvml := &fakeNVML{}
+	c := newNvidiaCollector(nvml, log.NewNopLogger())
+
+	want := `# HELP node_gpu_memory_used_bytes Allocated memory of the GPU.
+# TYPE node_gpu_memory_used_bytes gauge
+node_gpu_memory_used_bytes{index="0",uuid="GPU-6a1b-0"} 4.294967296e+09
+node_gpu_memory_used_bytes{index="1",uuid="GPU-6a1b-1"} 4.294967296e+09
+# HELP node_gpu_power_watts Power draw of the GPU and its memory.
+# TYPE node_gpu_power_watts gauge
+node_gpu_power_watts{index="0",uuid="GPU-6a1b-0"} 123.456
+# HELP node_gpu_temperature_celsius Temperature of the GPU die.
+# TYPE node_gpu_temperature_celsius gauge
+node_gpu_temperature_celsius{index="0",uuid="GPU-6a1b-0"} 65
+node_gpu_temperature_celsius{index="1",uuid="GPU-6a1b-1"} 65
+# HELP node_gpu_utilization_ratio Fraction of the last sample period a kernel was running on the GPU.
+# TYPE node_gpu_utilization_ratio gauge
+node_gpu_utilization_ratio{index="0",uuid="GPU-6a1b-0"} 0.42
+node_gpu_utilization_ratio{index="1",uuid="GPU-6a1b-1"} 0.43
+`
+	for i := 0; i < 2; i++ {
+		if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if nvml.inits != 1 {
+		t.Errorf("want NVML to be initialized once, got %d", nvml.inits)
+	}
+}
+
+func TestNvidiaCollectorNoDriver(t *testing.T) {
+	c := newNvidiaCollector(&missingNVML{}, log.NewNopLogger())
+	if err := c.Update(nil); err != ErrNoData {
+		t.Errorf("want ErrNoData, got %v", err)
+	}
+}
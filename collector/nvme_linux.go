@@ -0,0 +1,203 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonvme
+
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"runtime"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const nvmeSubsystem = "nvme"
+
+// Constants from linux/nvme_ioctl.h and the NVMe specification.
+const (
+	// nvmeIoctlAdminCmd is _IOWR('N', 0x41, struct nvme_admin_cmd).
+	nvmeIoctlAdminCmd = 0xc0484e41
+
+	nvmeAdminGetLogPage = 0x02
+	nvmeLogSmart        = 0x02
+	nvmeNSIDAll         = 0xffffffff
+	nvmeSmartLogLength  = 512
+)
+
+// nvmePassthruCmd is struct nvme_passthru_cmd from linux/nvme_ioctl.h.
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+// nvmeSmartLog holds the fields of the SMART / Health Information log page
+// exposed by the collector.
+type nvmeSmartLog struct {
+	// temperature is the composite temperature in Kelvin.
+	temperature uint16
+	// availableSpare and percentageUsed are percentages, the latter can
+	// exceed 100.
+	availableSpare uint8
+	percentageUsed uint8
+	mediaErrors    float64
+	powerOnHours   float64
+}
+
+type nvmeCollector struct {
+	// smartLog reads the SMART log of the controller, it is replaced in
+	// tests.
+	smartLog func(controller string) (nvmeSmartLog, error)
+
+	temperature, availableSpare, percentageUsed, mediaErrors, powerOnHours typedDesc
+	logger                                                                 log.Logger
+}
+
+func init() {
+	registerCollector(nvmeSubsystem, defaultDisabled, NewNVMeCollector)
+}
+
+// NewNVMeCollector returns a new Collector exposing the SMART / Health
+// Information of NVMe controllers.
+func NewNVMeCollector(logger log.Logger) (Collector, error) {
+	return &nvmeCollector{
+		smartLog: readNVMeSmartLog,
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nvmeSubsystem, "temperature_celsius"),
+			"Composite temperature of the controller.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		availableSpare: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nvmeSubsystem, "available_spare_ratio"),
+			"Remaining spare capacity of the controller.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		percentageUsed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nvmeSubsystem, "percentage_used_ratio"),
+			"Vendor specific estimate of the used life of the controller, values above 1 are possible.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		mediaErrors: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nvmeSubsystem, "media_errors_total"),
+			"Number of unrecovered data integrity errors of the controller.",
+			[]string{"device"}, nil,
+		), prometheus.CounterValue},
+		powerOnHours: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nvmeSubsystem, "power_on_hours"),
+			"Number of hours the controller was powered on.",
+			[]string{"device"}, nil,
+		), prometheus.CounterValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *nvmeCollector) Update(ch chan<- prometheus.Metric) error {
+	controllers, err := ioutil.ReadDir(sysFilePath("class/nvme"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "No NVMe controllers found, skipping", "err", err)
+			return ErrNoData
+		}
+		return fmt.Errorf("couldn't get NVMe controllers: %w", err)
+	}
+
+	for _, controller := range controllers {
+		device := controller.Name()
+		smart, err := c.smartLog(device)
+		if err != nil {
+			// All controllers need the same privileges, so there is no
+			// point in trying the others.
+			if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+				level.Debug(c.logger).Log("msg", "Not permitted to read the NVMe SMART log, skipping", "device", device, "err", err)
+				return ErrNoData
+			}
+			return fmt.Errorf("couldn't get SMART log of %s: %w", device, err)
+		}
+
+		ch <- c.temperature.mustNewConstMetric(float64(smart.temperature)-273, device)
+		ch <- c.availableSpare.mustNewConstMetric(float64(smart.availableSpare)/100, device)
+		ch <- c.percentageUsed.mustNewConstMetric(float64(smart.percentageUsed)/100, device)
+		ch <- c.mediaErrors.mustNewConstMetric(smart.mediaErrors, device)
+		ch <- c.powerOnHours.mustNewConstMetric(smart.powerOnHours, device)
+	}
+	return nil
+}
+
+// readNVMeSmartLog gets the SMART / Health Information log page of the
+// controller with the NVME_IOCTL_ADMIN_CMD ioctl, which needs CAP_SYS_ADMIN.
+func readNVMeSmartLog(controller string) (nvmeSmartLog, error) {
+	f, err := os.Open(rootfsFilePath("/dev/" + controller))
+	if err != nil {
+		return nvmeSmartLog{}, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, nvmeSmartLogLength)
+	cmd := nvmePassthruCmd{
+		opcode:  nvmeAdminGetLogPage,
+		nsid:    nvmeNSIDAll,
+		addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		dataLen: nvmeSmartLogLength,
+		// The number of dwords to read, minus one, and the log page.
+		cdw10: (nvmeSmartLogLength/4-1)<<16 | nvmeLogSmart,
+	}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), nvmeIoctlAdminCmd, uintptr(unsafe.Pointer(&cmd)))
+	runtime.KeepAlive(buf)
+	if errno != 0 {
+		return nvmeSmartLog{}, errno
+	}
+	return parseNVMeSmartLog(buf)
+}
+
+func parseNVMeSmartLog(b []byte) (nvmeSmartLog, error) {
+	if len(b) < nvmeSmartLogLength {
+		return nvmeSmartLog{}, fmt.Errorf("SMART log too short: %d bytes", len(b))
+	}
+	return nvmeSmartLog{
+		temperature:    binary.LittleEndian.Uint16(b[1:3]),
+		availableSpare: b[3],
+		percentageUsed: b[5],
+		powerOnHours:   nvmeUint128(b[128:144]),
+		mediaErrors:    nvmeUint128(b[160:176]),
+	}, nil
+}
+
+// nvmeUint128 converts a little endian 128 bit counter of a log page.
+func nvmeUint128(b []byte) float64 {
+	return float64(binary.LittleEndian.Uint64(b[:8])) + float64(binary.LittleEndian.Uint64(b[8:16]))*math.Pow(2, 64)
+}
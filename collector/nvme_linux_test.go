@@ -0,0 +1,98 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonvme
+
+package collector
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sys/unix"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestParseNVMeSmartLog(t *testing.T) {
+	b := make([]byte, nvmeSmartLogLength)
+	binary.LittleEndian.PutUint16(b[1:], 310)
+	b[3] = 100
+	b[5] = 3
+	binary.LittleEndian.PutUint64(b[128:], 8760)
+	binary.LittleEndian.PutUint64(b[160:], 2)
+	binary.LittleEndian.PutUint64(b[168:], 1)
+
+	want := nvmeSmartLog{
+		temperature:    310,
+		availableSpare: 100,
+		percentageUsed: 3,
+		powerOnHours:   8760,
+		mediaErrors:    2 + 1<<64,
+	}
+	got, err := parseNVMeSmartLog(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	if _, err := parseNVMeSmartLog(b[:64]); err == nil {
+		t.Error("expected an error for a short log page")
+	}
+}
+
+func TestNVMeCollector(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.sysfs", "fixtures/sys"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewNVMeCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc := c.(*nvmeCollector)
+	nc.smartLog = func(string) (nvmeSmartLog, error) {
+		return nvmeSmartLog{temperature: 310, availableSpare: 100, percentageUsed: 3, mediaErrors: 2, powerOnHours: 8760}, nil
+	}
+
+	want := `# HELP node_nvme_available_spare_ratio Remaining spare capacity of the controller.
+# TYPE node_nvme_available_spare_ratio gauge
+node_nvme_available_spare_ratio{device="nvme0"} 1
+# HELP node_nvme_media_errors_total Number of unrecovered data integrity errors of the controller.
+# TYPE node_nvme_media_errors_total counter
+node_nvme_media_errors_total{device="nvme0"} 2
+# HELP node_nvme_percentage_used_ratio Vendor specific estimate of the used life of the controller, values above 1 are possible.
+# TYPE node_nvme_percentage_used_ratio gauge
+node_nvme_percentage_used_ratio{device="nvme0"} 0.03
+# HELP node_nvme_power_on_hours Number of hours the controller was powered on.
+# TYPE node_nvme_power_on_hours counter
+node_nvme_power_on_hours{device="nvme0"} 8760
+# HELP node_nvme_temperature_celsius Composite temperature of the controller.
+# TYPE node_nvme_temperature_celsius gauge
+node_nvme_temperature_celsius{device="nvme0"} 37
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	nc.smartLog = func(string) (nvmeSmartLog, error) {
+		return nvmeSmartLog{}, unix.EACCES
+	}
+	if err := c.Update(make(chan prometheus.Metric, 5)); err != ErrNoData {
+		t.Fatalf("want ErrNoData without permission, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build nvidia,cgo
+// +build !nonvidia
+
+package collector
+
+import (
+	"fmt"
+)
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+
+// The subset of nvml.h used by the collector. The library is loaded at
+// runtime so the binary doesn't depend on the driver being installed.
+#define NVML_SUCCESS 0
+#define NVML_ERROR_LIBRARY_NOT_FOUND 12
+#define NVML_ERROR_FUNCTION_NOT_FOUND 13
+#define NVML_TEMPERATURE_GPU 0
+#define NVML_DEVICE_UUID_BUFFER_SIZE 80
+
+typedef int nvmlReturn_t;
+typedef void *nvmlDevice_t;
+typedef struct { unsigned int gpu, memory; } nvmlUtilization_t;
+typedef struct { unsigned long long total, free, used; } nvmlMemory_t;
+
+static nvmlReturn_t (*nvmlInitFn)(void);
+static const char *(*nvmlErrorStringFn)(nvmlReturn_t);
+static nvmlReturn_t (*nvmlDeviceGetCountFn)(unsigned int *);
+static nvmlReturn_t (*nvmlDeviceGetHandleByIndexFn)(unsigned int, nvmlDevice_t *);
+static nvmlReturn_t (*nvmlDeviceGetUUIDFn)(nvmlDevice_t, char *, unsigned int);
+static nvmlReturn_t (*nvmlDeviceGetUtilizationRatesFn)(nvmlDevice_t, nvmlUtilization_t *);
+static nvmlReturn_t (*nvmlDeviceGetMemoryInfoFn)(nvmlDevice_t, nvmlMemory_t *);
+static nvmlReturn_t (*nvmlDeviceGetTemperatureFn)(nvmlDevice_t, int, unsigned int *);
+static nvmlReturn_t (*nvmlDeviceGetPowerUsageFn)(nvmlDevice_t, unsigned int *);
+
+static void *nvmlLib;
+
+// nvmlOpen loads the library unless it is already loaded and initializes
+// NVML.
+static nvmlReturn_t nvmlOpen(void) {
+	if (nvmlLib != NULL) {
+		return nvmlInitFn();
+	}
+	void *lib = dlopen("libnvidia-ml.so.1", RTLD_NOW);
+	if (lib == NULL) {
+		return NVML_ERROR_LIBRARY_NOT_FOUND;
+	}
+	if ((nvmlInitFn = dlsym(lib, "nvmlInit_v2")) == NULL ||
+	    (nvmlErrorStringFn = dlsym(lib, "nvmlErrorString")) == NULL ||
+	    (nvmlDeviceGetCountFn = dlsym(lib, "nvmlDeviceGetCount_v2")) == NULL ||
+	    (nvmlDeviceGetHandleByIndexFn = dlsym(lib, "nvmlDeviceGetHandleByIndex_v2")) == NULL ||
+	    (nvmlDeviceGetUUIDFn = dlsym(lib, "nvmlDeviceGetUUID")) == NULL ||
+	    (nvmlDeviceGetUtilizationRatesFn = dlsym(lib, "nvmlDeviceGetUtilizationRates")) == NULL ||
+	    (nvmlDeviceGetMemoryInfoFn = dlsym(lib, "nvmlDeviceGetMemoryInfo")) == NULL ||
+	    (nvmlDeviceGetTemperatureFn = dlsym(lib, "nvmlDeviceGetTemperature")) == NULL ||
+	    (nvmlDeviceGetPowerUsageFn = dlsym(lib, "nvmlDeviceGetPowerUsage")) == NULL) {
+		dlclose(lib);
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlLib = lib;
+	return nvmlInitFn();
+}
+
+static const char *nvmlErrorString(nvmlReturn_t ret) {
+	if (ret == NVML_ERROR_LIBRARY_NOT_FOUND) {
+		return "libnvidia-ml.so.1 not found";
+	}
+	if (nvmlErrorStringFn == NULL) {
+		return "NVML library not loaded";
+	}
+	return nvmlErrorStringFn(ret);
+}
+
+static nvmlReturn_t nvmlDeviceGetCount(unsigned int *count) {
+	return nvmlDeviceGetCountFn(count);
+}
+
+static nvmlReturn_t nvmlDeviceGetHandleByIndex(unsigned int index, nvmlDevice_t *device) {
+	return nvmlDeviceGetHandleByIndexFn(index, device);
+}
+
+static nvmlReturn_t nvmlDeviceGetUUID(nvmlDevice_t device, char *uuid, unsigned int length) {
+	return nvmlDeviceGetUUIDFn(device, uuid, length);
+}
+
+static nvmlReturn_t nvmlDeviceGetUtilizationRates(nvmlDevice_t device, nvmlUtilization_t *utilization) {
+	return nvmlDeviceGetUtilizationRatesFn(device, utilization);
+}
+
+static nvmlReturn_t nvmlDeviceGetMemoryInfo(nvmlDevice_t device, nvmlMemory_t *memory) {
+	return nvmlDeviceGetMemoryInfoFn(device, memory);
+}
+
+static nvmlReturn_t nvmlDeviceGetTemperature(nvmlDevice_t device, unsigned int *temp) {
+	return nvmlDeviceGetTemperatureFn(device, NVML_TEMPERATURE_GPU, temp);
+}
+
+static nvmlReturn_t nvmlDeviceGetPowerUsage(nvmlDevice_t device, unsigned int *power) {
+	return nvmlDeviceGetPowerUsageFn(device, power);
+}
+*/
+import "C"
+
+// nvmlError is a failed NVML call.
+type nvmlError struct {
+	call string
+	ret  C.nvmlReturn_t
+}
+
+func (e nvmlError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.call, C.GoString(C.nvmlErrorString(e.ret)))
+}
+
+func nvmlCheck(call string, ret C.nvmlReturn_t) error {
+	if ret != C.NVML_SUCCESS {
+		return nvmlError{call: call, ret: ret}
+	}
+	return nil
+}
+
+// cgoNVML implements nvmlLibrary with the dynamically loaded NVML.
+type cgoNVML struct{}
+
+func newNVML() nvmlLibrary {
+	return cgoNVML{}
+}
+
+func (cgoNVML) Init() error {
+	return nvmlCheck("nvmlInit", C.nvmlOpen())
+}
+
+func (cgoNVML) DeviceCount() (int, error) {
+	var count C.uint
+	if err := nvmlCheck("nvmlDeviceGetCount", C.nvmlDeviceGetCount(&count)); err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func (cgoNVML) Device(index int) (nvmlDevice, error) {
+	var device C.nvmlDevice_t
+	if err := nvmlCheck("nvmlDeviceGetHandleByIndex", C.nvmlDeviceGetHandleByIndex(C.uint(index), &device)); err != nil {
+		return nil, err
+	}
+	return cgoNVMLDevice{device}, nil
+}
+
+type cgoNVMLDevice struct {
+	device C.nvmlDevice_t
+}
+
+func (d cgoNVMLDevice) UUID() (string, error) {
+	var uuid [C.NVML_DEVICE_UUID_BUFFER_SIZE]C.char
+	if err := nvmlCheck("nvmlDeviceGetUUID", C.nvmlDeviceGetUUID(d.device, &uuid[0], C.uint(len(uuid)))); err != nil {
+		return "", err
+	}
+	return C.GoString(&uuid[0]), nil
+}
+
+func (d cgoNVMLDevice) Utilization() (nvmlUtilization, error) {
+	var utilization C.nvmlUtilization_t
+	if err := nvmlCheck("nvmlDeviceGetUtilizationRates", C.nvmlDeviceGetUtilizationRates(d.device, &utilization)); err != nil {
+		return nvmlUtilization{}, err
+	}
+	return nvmlUtilization{gpu: uint32(utilization.gpu), memory: uint32(utilization.memory)}, nil
+}
+
+func (d cgoNVMLDevice) Memory() (nvmlMemory, error) {
+	var memory C.nvmlMemory_t
+	if err := nvmlCheck("nvmlDeviceGetMemoryInfo", C.nvmlDeviceGetMemoryInfo(d.device, &memory)); err != nil {
+		return nvmlMemory{}, err
+	}
+	return nvmlMemory{total: uint64(memory.total), free: uint64(memory.free), used: uint64(memory.used)}, nil
+}
+
+func (d cgoNVMLDevice) Temperature() (uint32, error) {
+	var temperature C.uint
+	if err := nvmlCheck("nvmlDeviceGetTemperature", C.nvmlDeviceGetTemperature(d.device, &temperature)); err != nil {
+		return 0, err
+	}
+	return uint32(temperature), nil
+}
+
+func (d cgoNVMLDevice) PowerUsage() (uint32, error) {
+	var power C.uint
+	if err := nvmlCheck("nvmlDeviceGetPowerUsage", C.nvmlDeviceGetPowerUsage(d.device, &power)); err != nil {
+		return 0, err
+	}
+	return uint32(power), nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noos
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const osSubsystem = "os"
+
+// osReleaseFiles are the locations of os-release(5), /etc/os-release takes
+// precedence.
+var osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// osVersionRE matches the numeric part of VERSION_ID, like 20.04 of 20.04 or
+// 7.9 of 7.9.2009.
+var osVersionRE = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?`)
+
+type osReleaseCollector struct {
+	info    typedDesc
+	version typedDesc
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector(osSubsystem, defaultEnabled, NewOSCollector)
+}
+
+// NewOSCollector returns a new Collector exposing the operating system
+// identification from os-release.
+func NewOSCollector(logger log.Logger) (Collector, error) {
+	return &osReleaseCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, osSubsystem, "info"),
+			"Operating system identification from os-release, value is always 1.",
+			[]string{"name", "version", "version_id", "id"}, nil,
+		), prometheus.GaugeValue},
+		version: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, osSubsystem, "version"),
+			"Numeric part of VERSION_ID of os-release.",
+			[]string{"id"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *osReleaseCollector) Update(ch chan<- prometheus.Metric) error {
+	var (
+		file *os.File
+		err  error
+	)
+	for _, path := range osReleaseFiles {
+		file, err = os.Open(rootfsFilePath(path))
+		if !os.IsNotExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			level.Debug(c.logger).Log("msg", "No os-release file found, skipping")
+			return ErrNoData
+		}
+		return err
+	}
+	defer file.Close()
+
+	release, err := parseOSRelease(file, c.logger)
+	if err != nil {
+		return fmt.Errorf("couldn't parse os-release: %w", err)
+	}
+
+	ch <- c.info.mustNewConstMetric(1, release["NAME"], release["VERSION"], release["VERSION_ID"], release["ID"])
+	if v := osVersionRE.FindString(release["VERSION_ID"]); v != "" {
+		version, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		ch <- c.version.mustNewConstMetric(version, release["ID"])
+	}
+	return nil
+}
+
+// parseOSRelease returns the variables of an os-release file, which has
+// shell compatible KEY=value lines with optionally quoted values. Lines
+// without an assignment are skipped like systemd does.
+func parseOSRelease(r io.Reader, logger log.Logger) (map[string]string, error) {
+	release := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			level.Debug(logger).Log("msg", "Ignoring invalid line in os-release", "line", line)
+			continue
+		}
+		value, err := unquoteOSReleaseValue(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s in os-release: %w", parts[0], err)
+		}
+		release[parts[0]] = value
+	}
+	return release, scanner.Err()
+}
+
+// unquoteOSReleaseValue removes the single or double quotes around a value,
+// in double quotes a backslash escapes $, ", \ and `.
+func unquoteOSReleaseValue(value string) (string, error) {
+	if len(value) == 0 || (value[0] != '"' && value[0] != '\'') {
+		return value, nil
+	}
+	quote := value[0]
+	if len(value) < 2 || value[len(value)-1] != quote {
+		return "", fmt.Errorf("unterminated quote in %q", value)
+	}
+	value = value[1 : len(value)-1]
+	if quote == '\'' {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && strings.IndexByte("$\"\\`", value[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noos
+
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	const osRelease = `# Comment
+NAME="Ubuntu"
+VERSION="20.04 LTS (Focal Fossa)"
+ID=ubuntu
+ID_LIKE=debian
+export
+VERSION_ID='20.04'
+
+PRETTY_NAME="Ubuntu \"Focal\" \$ 20.04"
+`
+	release, err := parseOSRelease(strings.NewReader(osRelease), log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"NAME":        "Ubuntu",
+		"VERSION":     "20.04 LTS (Focal Fossa)",
+		"ID":          "ubuntu",
+		"ID_LIKE":     "debian",
+		"VERSION_ID":  "20.04",
+		"PRETTY_NAME": `Ubuntu "Focal" $ 20.04`,
+	}
+	if !reflect.DeepEqual(release, want) {
+		t.Errorf("want %v, got %v", want, release)
+	}
+
+	if _, err := parseOSRelease(strings.NewReader(`NAME="Ubuntu`), log.NewNopLogger()); err == nil {
+		t.Error("want error for unterminated quote")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noovs
+
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const (
+	ovsSubsystem = "ovs"
+
+	ovsRunDir = "/var/run/openvswitch"
+)
+
+var (
+	ovsSocket = kingpin.Flag("collector.ovs.socket", "Control socket of ovs-vswitchd, found through its pid file in "+ovsRunDir+" if empty.").Default("").String()
+)
+
+type ovsCollector struct {
+	socket             string
+	flows, hit, missed typedDesc
+	logger             log.Logger
+}
+
+// ovsDatapathStats are the statistics of a datapath as shown by
+// ovs-appctl dpctl/show.
+type ovsDatapathStats struct {
+	name               string
+	hit, missed, flows float64
+}
+
+// ovsRequest and ovsResponse are the JSON-RPC messages of the control
+// socket, the result of a command is its text output.
+type ovsRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+type ovsResponse struct {
+	Result *string     `json:"result"`
+	Error  interface{} `json:"error"`
+	ID     int         `json:"id"`
+}
+
+func init() {
+	registerCollector(ovsSubsystem, defaultDisabled, NewOVSCollector)
+}
+
+// NewOVSCollector returns a new Collector exposing the datapath statistics of
+// Open vSwitch.
+func NewOVSCollector(logger log.Logger) (Collector, error) {
+	return &ovsCollector{
+		socket: *ovsSocket,
+		flows: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ovsSubsystem, "dp_flows"),
+			"Number of flows in the datapath.",
+			[]string{"datapath"}, nil,
+		), prometheus.GaugeValue},
+		hit: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ovsSubsystem, "dp_hit_total"),
+			"Number of packets matching an existing flow of the datapath.",
+			[]string{"datapath"}, nil,
+		), prometheus.CounterValue},
+		missed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, ovsSubsystem, "dp_missed_total"),
+			"Number of packets not matching any flow of the datapath, sent to ovs-vswitchd.",
+			[]string{"datapath"}, nil,
+		), prometheus.CounterValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *ovsCollector) Update(ch chan<- prometheus.Metric) error {
+	socket := c.socket
+	if socket == "" {
+		var err error
+		if socket, err = ovsDefaultSocket(); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				level.Debug(c.logger).Log("msg", "ovs-vswitchd is not running, skipping", "err", err)
+				return ErrNoData
+			}
+			return fmt.Errorf("couldn't find ovs-vswitchd control socket: %w", err)
+		}
+	}
+
+	out, err := ovsCommand(socket, "dpctl/show", time.Second)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+			level.Debug(c.logger).Log("msg", "ovs-vswitchd is not running, skipping", "socket", socket, "err", err)
+			return ErrNoData
+		}
+		return fmt.Errorf("couldn't get datapath statistics: %w", err)
+	}
+
+	datapaths, err := parseOVSDatapaths(out)
+	if err != nil {
+		return fmt.Errorf("couldn't parse datapath statistics: %w", err)
+	}
+	for _, dp := range datapaths {
+		ch <- c.flows.mustNewConstMetric(dp.flows, dp.name)
+		ch <- c.hit.mustNewConstMetric(dp.hit, dp.name)
+		ch <- c.missed.mustNewConstMetric(dp.missed, dp.name)
+	}
+	return nil
+}
+
+// ovsDefaultSocket returns the control socket of the running ovs-vswitchd,
+// it is named after the pid of the daemon.
+func ovsDefaultSocket() (string, error) {
+	pid, err := ioutil.ReadFile(rootfsFilePath(ovsRunDir + "/ovs-vswitchd.pid"))
+	if err != nil {
+		return "", err
+	}
+	return rootfsFilePath(fmt.Sprintf("%s/ovs-vswitchd.%s.ctl", ovsRunDir, strings.TrimSpace(string(pid)))), nil
+}
+
+// ovsCommand runs a command of ovs-appctl on the control socket and returns
+// its output.
+func ovsCommand(socket, command string, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("unix", socket, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return "", err
+	}
+
+	if err := json.NewEncoder(conn).Encode(ovsRequest{Method: command, Params: []string{}}); err != nil {
+		return "", err
+	}
+	var resp ovsResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", err
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("%s failed: %v", command, resp.Error)
+	}
+	if resp.Result == nil {
+		return "", fmt.Errorf("%s returned no result", command)
+	}
+	return *resp.Result, nil
+}
+
+// parseOVSDatapaths parses the output of dpctl/show, which has a line with
+// the name of each datapath followed by indented lines with its details:
+//
+//	system@ovs-system:
+//	  lookups: hit:1234 missed:56 lost:0
+//	  flows: 7
+func parseOVSDatapaths(out string) ([]ovsDatapathStats, error) {
+	var (
+		datapaths []ovsDatapathStats
+		scanner   = bufio.NewScanner(strings.NewReader(out))
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			datapaths = append(datapaths, ovsDatapathStats{name: strings.TrimSuffix(line, ":")})
+			continue
+		}
+		if len(datapaths) == 0 {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		dp := &datapaths[len(datapaths)-1]
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "lookups:":
+			for _, field := range fields[1:] {
+				parts := strings.SplitN(field, ":", 2)
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("invalid lookups field %q", field)
+				}
+				value, err := strconv.ParseFloat(parts[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid lookups field %q: %w", field, err)
+				}
+				switch parts[0] {
+				case "hit":
+					dp.hit = value
+				case "missed":
+					dp.missed = value
+				}
+			}
+		case "flows:":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("unexpected line %q", line)
+			}
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid flows %q: %w", fields[1], err)
+			}
+			dp.flows = value
+		}
+	}
+	return datapaths, scanner.Err()
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noovs
+
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const ovsDpctlShow = `system@ovs-system:
+  lookups: hit:1234 missed:56 lost:0
+  flows: 7
+  masks: hit:2345 total:2 hit/pkt:1.82
+  port 0: ovs-system (internal)
+  port 1: br0 (internal)
+netdev@ovs-netdev:
+  lookups: hit:10 missed:2 lost:0
+  flows: 1
+  port 0: ovs-netdev (tap)
+`
+
+func TestParseOVSDatapaths(t *testing.T) {
+	datapaths, err := parseOVSDatapaths(ovsDpctlShow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ovsDatapathStats{
+		{name: "system@ovs-system", hit: 1234, missed: 56, flows: 7},
+		{name: "netdev@ovs-netdev", hit: 10, missed: 2, flows: 1},
+	}
+	if len(datapaths) != len(want) {
+		t.Fatalf("want %d datapaths, got %v", len(want), datapaths)
+	}
+	for i := range want {
+		if datapaths[i] != want[i] {
+			t.Errorf("want %+v, got %+v", want[i], datapaths[i])
+		}
+	}
+
+	if _, err := parseOVSDatapaths("  flows: 7\n"); err == nil {
+		t.Error("expected an error for a line outside of a datapath")
+	}
+}
+
+func TestOVSCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ovs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "ovs-vswitchd.ctl")
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req ovsRequest
+		if err := json.NewDecoder(conn).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != "dpctl/show" {
+			t.Errorf("unexpected method %q", req.Method)
+		}
+		result := ovsDpctlShow
+		json.NewEncoder(conn).Encode(ovsResponse{Result: &result, ID: req.ID})
+	}()
+
+	c, err := NewOVSCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*ovsCollector).socket = socket
+
+	expected := `
+# HELP node_ovs_dp_flows Number of flows in the datapath.
+# TYPE node_ovs_dp_flows gauge
+node_ovs_dp_flows{datapath="netdev@ovs-netdev"} 1
+node_ovs_dp_flows{datapath="system@ovs-system"} 7
+# HELP node_ovs_dp_hit_total Number of packets matching an existing flow of the datapath.
+# TYPE node_ovs_dp_hit_total counter
+node_ovs_dp_hit_total{datapath="netdev@ovs-netdev"} 10
+node_ovs_dp_hit_total{datapath="system@ovs-system"} 1234
+# HELP node_ovs_dp_missed_total Number of packets not matching any flow of the datapath, sent to ovs-vswitchd.
+# TYPE node_ovs_dp_missed_total counter
+node_ovs_dp_missed_total{datapath="netdev@ovs-netdev"} 2
+node_ovs_dp_missed_total{datapath="system@ovs-system"} 56
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOVSCollectorNotRunning(t *testing.T) {
+	c, err := NewOVSCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*ovsCollector).socket = filepath.Join(os.TempDir(), "node_exporter-no-ovs.ctl")
+	if err := c.Update(nil); err != ErrNoData {
+		t.Errorf("want ErrNoData, got %v", err)
+	}
+}
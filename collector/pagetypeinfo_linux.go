@@ -0,0 +1,127 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopagetypeinfo
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pagetypeinfoSubsystem = "pagetypeinfo"
+
+// pagetypeinfoFreePages is the number of free blocks of a migrate type and
+// order in a zone.
+type pagetypeinfoFreePages struct {
+	node, zone, migrateType, order string
+	count                          float64
+}
+
+type pagetypeinfoCollector struct {
+	freePages typedDesc
+	logger    log.Logger
+}
+
+func init() {
+	registerCollector(pagetypeinfoSubsystem, defaultDisabled, NewPagetypeinfoCollector)
+}
+
+// NewPagetypeinfoCollector returns a new Collector exposing the free pages
+// per migrate type and order from /proc/pagetypeinfo.
+func NewPagetypeinfoCollector(logger log.Logger) (Collector, error) {
+	return &pagetypeinfoCollector{
+		freePages: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, pagetypeinfoSubsystem, "free_pages_count"),
+			"Number of free blocks of 2^order pages per migrate type (Warning: a series for each node, zone, type and order).",
+			[]string{"node", "zone", "type", "order"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *pagetypeinfoCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("pagetypeinfo"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	freePages, err := parsePagetypeinfo(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse pagetypeinfo: %w", err)
+	}
+	for _, p := range freePages {
+		ch <- c.freePages.mustNewConstMetric(p.count, p.node, p.zone, p.migrateType, p.order)
+	}
+	return nil
+}
+
+// parsePagetypeinfo parses the free pages table of pagetypeinfo. Its header
+// lists the orders, which depend on the kernel configuration, and it ends
+// with an empty line before the table of the number of blocks.
+func parsePagetypeinfo(r io.Reader) ([]pagetypeinfoFreePages, error) {
+	var (
+		freePages []pagetypeinfoFreePages
+		orders    []string
+		scanner   = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if orders == nil {
+			if strings.HasPrefix(line, "Free pages count per migrate type at order") {
+				orders = strings.Fields(strings.TrimPrefix(line, "Free pages count per migrate type at order"))
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		// Node    0, zone      DMA, type    Unmovable      0      0 ...
+		fields := strings.Fields(line)
+		if len(fields) != 6+len(orders) || fields[0] != "Node" || fields[2] != "zone" || fields[4] != "type" {
+			return nil, fmt.Errorf("invalid line in pagetypeinfo: %q", line)
+		}
+		node := strings.TrimSuffix(fields[1], ",")
+		zone := strings.TrimSuffix(fields[3], ",")
+		for i, order := range orders {
+			count, err := strconv.ParseFloat(fields[6+i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in pagetypeinfo: %w", fields[6+i], err)
+			}
+			freePages = append(freePages, pagetypeinfoFreePages{
+				node:        node,
+				zone:        zone,
+				migrateType: fields[5],
+				order:       order,
+				count:       count,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		return nil, fmt.Errorf("free pages table not found")
+	}
+	return freePages, nil
+}
@@ -16,6 +16,7 @@
 package collector
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
 	"strconv"
@@ -36,6 +37,10 @@ const (
 var (
 	perfCPUsFlag       = kingpin.Flag("collector.perf.cpus", "List of CPUs from which perf metrics should be collected").Default("").String()
 	perfTracepointFlag = kingpin.Flag("collector.perf.tracepoint", "perf tracepoint that should be collected").Strings()
+
+	// newPerfProbe opens the event used to check the access to perf events,
+	// it is replaced in tests.
+	newPerfProbe = perf.NewCPUClockProfiler
 )
 
 func init() {
@@ -135,6 +140,8 @@ type perfCollector struct {
 	desc                map[string]*prometheus.Desc
 	logger              log.Logger
 	tracepointCollector *perfTracepointCollector
+	// noAccess is set if perf_event_open(2) isn't permitted.
+	noAccess bool
 }
 
 type perfTracepointCollector struct {
@@ -271,6 +278,18 @@ func NewPerfCollector(logger log.Logger) (Collector, error) {
 		}
 	}
 
+	// Without permission to open perf events none of the profilers below
+	// would start, so check once and degrade instead of failing startup.
+	probe, err := newPerfProbe(-1, cpus[0])
+	if errors.Is(err, unix.EACCES) || errors.Is(err, unix.EPERM) {
+		level.Warn(logger).Log("msg", "perf_event_open is not permitted, perf collector will not return any data. Grant CAP_PERFMON or lower kernel.perf_event_paranoid", "err", err)
+		collector.noAccess = true
+		return collector, nil
+	}
+	if err == nil {
+		probe.Close()
+	}
+
 	// First configure any tracepoints.
 	if *perfTracepointFlag != nil && len(*perfTracepointFlag) > 0 {
 		tracepointCollector, err := newPerfTracepointCollector(logger, *perfTracepointFlag, cpus)
@@ -554,6 +573,10 @@ func NewPerfCollector(logger log.Logger) (Collector, error) {
 
 // Update implements the Collector interface and will collect metrics per CPU.
 func (c *perfCollector) Update(ch chan<- prometheus.Metric) error {
+	if c.noAccess {
+		return ErrNoData
+	}
+
 	if err := c.updateHardwareStats(ch); err != nil {
 		return err
 	}
@@ -16,6 +16,7 @@
 package collector
 
 import (
+	"fmt"
 	"io/ioutil"
 	"runtime"
 	"strconv"
@@ -23,6 +24,8 @@ import (
 	"testing"
 
 	"github.com/go-kit/kit/log"
+	"github.com/hodgesds/perf-utils"
+	"golang.org/x/sys/unix"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -61,6 +64,26 @@ func TestPerfCollector(t *testing.T) {
 	}
 }
 
+func TestPerfCollectorNoAccess(t *testing.T) {
+	defer func(probe func(int, int, ...int) (perf.Profiler, error)) { newPerfProbe = probe }(newPerfProbe)
+
+	for _, errno := range []unix.Errno{unix.EACCES, unix.EPERM} {
+		newPerfProbe = func(pid, cpu int, opts ...int) (perf.Profiler, error) {
+			return nil, fmt.Errorf("failed to open perf event: %w", errno)
+		}
+		collector, err := NewPerfCollector(log.NewNopLogger())
+		if err != nil {
+			t.Fatalf("%v: want the collector to degrade, got %v", errno, err)
+		}
+		if !collector.(*perfCollector).noAccess {
+			t.Errorf("%v: want noAccess to be set", errno)
+		}
+		if err := collector.Update(nil); err != ErrNoData {
+			t.Errorf("%v: want ErrNoData, got %v", errno, err)
+		}
+	}
+}
+
 func TestPerfCollectorStride(t *testing.T) {
 	canTestPerf(t)
 
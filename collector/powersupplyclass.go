@@ -44,7 +44,10 @@ func init() {
 }
 
 func NewPowerSupplyClassCollector(logger log.Logger) (Collector, error) {
-	pattern := regexp.MustCompile(*powerSupplyClassIgnoredPowerSupplies)
+	pattern, err := regexp.Compile(*powerSupplyClassIgnoredPowerSupplies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --collector.powersupply.ignored-supplies: %w", err)
+	}
 	return &powerSupplyClassCollector{
 		subsystem:      "power_supply",
 		ignoredPattern: pattern,
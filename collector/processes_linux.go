@@ -19,11 +19,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/procfs"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	processGroups     = kingpin.Flag("collector.processes.group", "Aggregate processes matching a regexp into a group, given as name=regexp. Can be repeated.").Strings()
+	processGroupMatch = kingpin.Flag("collector.processes.group-match", "Match --collector.processes.group against the process comm or its full cmdline.").Default("comm").Enum("comm", "cmdline")
 )
 
 type processCollector struct {
@@ -34,6 +42,26 @@ type processCollector struct {
 	pidUsed     *prometheus.Desc
 	pidMax      *prometheus.Desc
 	logger      log.Logger
+
+	groups       []processGroup
+	matchCmdline bool
+	groupCPU     *prometheus.Desc
+	groupMemory  *prometheus.Desc
+	groupThreads *prometheus.Desc
+	groupProcs   *prometheus.Desc
+}
+
+type processGroup struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// processGroupStats holds the summed up stats of all processes of a group.
+type processGroupStats struct {
+	cpuSeconds  float64
+	memoryBytes float64
+	threads     float64
+	procs       float64
 }
 
 func init() {
@@ -46,6 +74,10 @@ func NewProcessStatCollector(logger log.Logger) (Collector, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open procfs: %w", err)
 	}
+	groups, err := parseProcessGroups(*processGroups)
+	if err != nil {
+		return nil, err
+	}
 	subsystem := "processes"
 	return &processCollector{
 		fs: fs,
@@ -71,8 +103,53 @@ func NewProcessStatCollector(logger log.Logger) (Collector, error) {
 			"Number of max PIDs limit", nil, nil,
 		),
 		logger: logger,
+
+		groups:       groups,
+		matchCmdline: *processGroupMatch == "cmdline",
+		groupCPU: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "processgroup", "cpu_seconds_total"),
+			"Seconds the processes of the group spent in user and system mode.",
+			[]string{"group"}, nil,
+		),
+		groupMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "processgroup", "memory_bytes"),
+			"Resident memory size of the processes of the group in bytes.",
+			[]string{"group"}, nil,
+		),
+		groupThreads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "processgroup", "threads"),
+			"Number of threads of the processes of the group.",
+			[]string{"group"}, nil,
+		),
+		groupProcs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "processgroup", "processes"),
+			"Number of processes in the group.",
+			[]string{"group"}, nil,
+		),
 	}, nil
 }
+
+func parseProcessGroups(flags []string) ([]processGroup, error) {
+	groups := make([]processGroup, 0, len(flags))
+	seen := map[string]bool{}
+	for _, f := range flags {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --collector.processes.group %q, expected name=regexp", f)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("duplicate --collector.processes.group name %q", parts[0])
+		}
+		seen[parts[0]] = true
+		pattern, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --collector.processes.group %q: %w", f, err)
+		}
+		groups = append(groups, processGroup{name: parts[0], pattern: pattern})
+	}
+	return groups, nil
+}
+
 func (c *processCollector) Update(ch chan<- prometheus.Metric) error {
 	pids, states, threads, err := c.getAllocatedThreads()
 	if err != nil {
@@ -97,6 +174,20 @@ func (c *processCollector) Update(ch chan<- prometheus.Metric) error {
 	ch <- prometheus.MustNewConstMetric(c.pidUsed, prometheus.GaugeValue, float64(pids))
 	ch <- prometheus.MustNewConstMetric(c.pidMax, prometheus.GaugeValue, float64(pidM))
 
+	if len(c.groups) == 0 {
+		return nil
+	}
+	groupStats, err := c.getProcessGroups()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve process group stats: %w", err)
+	}
+	for i, g := range c.groups {
+		ch <- prometheus.MustNewConstMetric(c.groupCPU, prometheus.CounterValue, groupStats[i].cpuSeconds, g.name)
+		ch <- prometheus.MustNewConstMetric(c.groupMemory, prometheus.GaugeValue, groupStats[i].memoryBytes, g.name)
+		ch <- prometheus.MustNewConstMetric(c.groupThreads, prometheus.GaugeValue, groupStats[i].threads, g.name)
+		ch <- prometheus.MustNewConstMetric(c.groupProcs, prometheus.GaugeValue, groupStats[i].procs, g.name)
+	}
+
 	return nil
 }
 
@@ -125,3 +216,48 @@ func (c *processCollector) getAllocatedThreads() (int, map[string]int32, int, er
 	}
 	return pids, procStates, thread, nil
 }
+
+// getProcessGroups sums up the stats of the processes matching each group,
+// in the order of c.groups. A process is counted in every group it matches.
+func (c *processCollector) getProcessGroups() ([]processGroupStats, error) {
+	p, err := c.fs.AllProcs()
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]processGroupStats, len(c.groups))
+	for _, pid := range p {
+		stat, err := pid.Stat()
+		// PIDs can vanish between getting the list and getting stats.
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "file not found when retrieving stats for pid", "pid", pid, "err", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := stat.Comm
+		if c.matchCmdline {
+			cmdline, err := pid.CmdLine()
+			if errors.Is(err, os.ErrNotExist) {
+				level.Debug(c.logger).Log("msg", "file not found when retrieving cmdline for pid", "pid", pid, "err", err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			name = strings.Join(cmdline, " ")
+		}
+
+		for i, g := range c.groups {
+			if !g.pattern.MatchString(name) {
+				continue
+			}
+			stats[i].cpuSeconds += stat.CPUTime()
+			stats[i].memoryBytes += float64(stat.ResidentMemory())
+			stats[i].threads += float64(stat.NumThreads)
+			stats[i].procs++
+		}
+	}
+	return stats, nil
+}
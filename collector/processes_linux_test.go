@@ -52,3 +52,35 @@ func TestReadProcessStatus(t *testing.T) {
 		t.Fatalf("Total running pids cannot be greater than %d or equals to 0", maxPid)
 	}
 }
+
+func TestProcessGroups(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.procfs", "fixtures/proc"}); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := procfs.NewFS(*procPath)
+	if err != nil {
+		t.Errorf("failed to open procfs: %v", err)
+	}
+	groups, err := parseProcessGroups([]string{"kernel=khung.*", "none=nomatch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := processCollector{fs: fs, groups: groups, logger: log.NewNopLogger()}
+	stats, err := c.getProcessGroups()
+	if err != nil {
+		t.Fatalf("Cannot retrieve data from procfs getProcessGroups function: %v", err)
+	}
+	if want, got := (processGroupStats{cpuSeconds: 0.14, threads: 1, procs: 1}), stats[0]; want.procs != got.procs || want.threads != got.threads || want.cpuSeconds != got.cpuSeconds {
+		t.Errorf("want group stats %+v, got %+v", want, got)
+	}
+	if stats[1].procs != 0 {
+		t.Errorf("want no processes in group none, got %v", stats[1].procs)
+	}
+
+	if _, err := parseProcessGroups([]string{"khungtaskd"}); err == nil {
+		t.Error("expected an error for a group without a name")
+	}
+	if _, err := parseProcessGroups([]string{"kernel=khung.*", "kernel=kworker.*"}); err == nil {
+		t.Error("expected an error for a duplicate group name")
+	}
+}
@@ -17,6 +17,7 @@ package collector
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 
@@ -34,11 +35,15 @@ type qdiscStatCollector struct {
 	overlimits typedDesc
 	qlength    typedDesc
 	backlog    typedDesc
-	logger     log.Logger
+	// children is set if child qdiscs are reported too, they are told
+	// apart by the handle and parent labels.
+	children bool
+	logger   log.Logger
 }
 
 var (
-	collectorQdisc = kingpin.Flag("collector.qdisc.fixtures", "test fixtures to use for qdisc collector end-to-end testing").Default("").String()
+	collectorQdisc         = kingpin.Flag("collector.qdisc.fixtures", "test fixtures to use for qdisc collector end-to-end testing").Default("").String()
+	collectorQdiscChildren = kingpin.Flag("collector.qdisc.include-children", "Report child qdiscs too, labeled by handle and parent.").Default("false").Bool()
 )
 
 func init() {
@@ -47,43 +52,48 @@ func init() {
 
 // NewQdiscStatCollector returns a new Collector exposing queuing discipline statistics.
 func NewQdiscStatCollector(logger log.Logger) (Collector, error) {
+	labels := []string{"device", "kind"}
+	if *collectorQdiscChildren {
+		labels = append(labels, "handle", "parent")
+	}
 	return &qdiscStatCollector{
 		bytes: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "bytes_total"),
 			"Number of bytes sent.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.CounterValue},
 		packets: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "packets_total"),
 			"Number of packets sent.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.CounterValue},
 		drops: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "drops_total"),
 			"Number of packets dropped.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.CounterValue},
 		requeues: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "requeues_total"),
 			"Number of packets dequeued, not transmitted, and requeued.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.CounterValue},
 		overlimits: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "overlimits_total"),
 			"Number of overlimit packets.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.CounterValue},
 		qlength: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "current_queue_length"),
 			"Number of packets currently in queue to be sent.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.GaugeValue},
 		backlog: typedDesc{prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "qdisc", "backlog"),
 			"Number of bytes currently in queue to be sent.",
-			[]string{"device", "kind"}, nil,
+			labels, nil,
 		), prometheus.GaugeValue},
-		logger: logger,
+		children: *collectorQdiscChildren,
+		logger:   logger,
 	}, nil
 }
 
@@ -116,19 +126,30 @@ func (c *qdiscStatCollector) Update(ch chan<- prometheus.Metric) error {
 	}
 
 	for _, msg := range msgs {
-		// Only report root qdisc information.
-		if msg.Parent != 0 {
+		labels := []string{msg.IfaceName, msg.Kind}
+		if c.children {
+			// Leaf qdiscs of classful qdiscs like HTB are where packets of
+			// shaped traffic are dropped. The children of mq all share
+			// handle 0:, so the parent is needed to tell them apart.
+			labels = append(labels, qdiscHandle(msg.Handle), qdiscHandle(msg.Parent))
+		} else if msg.Parent != 0 {
+			// Only report root qdisc information.
 			continue
 		}
-
-		ch <- c.bytes.mustNewConstMetric(float64(msg.Bytes), msg.IfaceName, msg.Kind)
-		ch <- c.packets.mustNewConstMetric(float64(msg.Packets), msg.IfaceName, msg.Kind)
-		ch <- c.drops.mustNewConstMetric(float64(msg.Drops), msg.IfaceName, msg.Kind)
-		ch <- c.requeues.mustNewConstMetric(float64(msg.Requeues), msg.IfaceName, msg.Kind)
-		ch <- c.overlimits.mustNewConstMetric(float64(msg.Overlimits), msg.IfaceName, msg.Kind)
-		ch <- c.qlength.mustNewConstMetric(float64(msg.Qlen), msg.IfaceName, msg.Kind)
-		ch <- c.backlog.mustNewConstMetric(float64(msg.Backlog), msg.IfaceName, msg.Kind)
+		ch <- c.bytes.mustNewConstMetric(float64(msg.Bytes), labels...)
+		ch <- c.packets.mustNewConstMetric(float64(msg.Packets), labels...)
+		ch <- c.drops.mustNewConstMetric(float64(msg.Drops), labels...)
+		ch <- c.requeues.mustNewConstMetric(float64(msg.Requeues), labels...)
+		ch <- c.overlimits.mustNewConstMetric(float64(msg.Overlimits), labels...)
+		ch <- c.qlength.mustNewConstMetric(float64(msg.Qlen), labels...)
+		ch <- c.backlog.mustNewConstMetric(float64(msg.Backlog), labels...)
 	}
 
 	return nil
 }
+
+// qdiscHandle formats a qdisc handle in the major:minor notation of tc, like
+// "8001:0".
+func qdiscHandle(handle uint32) string {
+	return fmt.Sprintf("%x:%x", handle>>16, handle&0xffff)
+}
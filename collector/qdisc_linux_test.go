@@ -0,0 +1,73 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noqdisc
+
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestQdiscStatCollector(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "root qdiscs",
+			want: `# HELP node_qdisc_bytes_total Number of bytes sent.
+# TYPE node_qdisc_bytes_total counter
+node_qdisc_bytes_total{device="eth0",kind="pfifo_fast"} 83
+node_qdisc_bytes_total{device="eth1",kind="htb"} 2100
+node_qdisc_bytes_total{device="eth2",kind="mq"} 300
+node_qdisc_bytes_total{device="wlan0",kind="fq"} 42
+`,
+		},
+		{
+			name: "child qdiscs",
+			args: []string{"--collector.qdisc.include-children"},
+			want: `# HELP node_qdisc_bytes_total Number of bytes sent.
+# TYPE node_qdisc_bytes_total counter
+node_qdisc_bytes_total{device="eth0",handle="0:0",kind="pfifo_fast",parent="0:0"} 83
+node_qdisc_bytes_total{device="eth1",handle="1000:0",kind="fq_codel",parent="1:10"} 2100
+node_qdisc_bytes_total{device="eth1",handle="1:0",kind="htb",parent="0:0"} 2100
+node_qdisc_bytes_total{device="eth2",handle="0:0",kind="fq_codel",parent="0:1"} 100
+node_qdisc_bytes_total{device="eth2",handle="0:0",kind="fq_codel",parent="0:2"} 200
+node_qdisc_bytes_total{device="eth2",handle="0:0",kind="mq",parent="0:0"} 300
+node_qdisc_bytes_total{device="wlan0",handle="0:0",kind="fq",parent="0:0"} 42
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := kingpin.CommandLine.Parse(append([]string{"--collector.qdisc.fixtures", "fixtures/qdisc/"}, tc.args...)); err != nil {
+				t.Fatal(err)
+			}
+			defer kingpin.CommandLine.Parse([]string{})
+
+			c, err := NewQdiscStatCollector(log.NewNopLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(tc.want), "node_qdisc_bytes_total"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
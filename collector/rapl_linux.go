@@ -16,15 +16,20 @@
 package collector
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/procfs/sysfs"
 )
 
 type raplCollector struct {
-	fs sysfs.FS
+	fs     sysfs.FS
+	logger log.Logger
 }
 
 func init() {
@@ -40,17 +45,23 @@ func NewRaplCollector(logger log.Logger) (Collector, error) {
 	}
 
 	collector := raplCollector{
-		fs: fs,
+		fs:     fs,
+		logger: logger,
 	}
 	return &collector, nil
 }
 
 // Update implements Collector and exposes RAPL related metrics.
 func (c *raplCollector) Update(ch chan<- prometheus.Metric) error {
-	// nil zones are fine when platform doesn't have powercap files present.
+	// GetRaplZones doesn't tell why it failed, so check for a missing
+	// powercap class first.
+	if _, err := os.Stat(sysFilePath("class/powercap")); errors.Is(err, os.ErrNotExist) {
+		level.Debug(c.logger).Log("msg", "Platform doesn't have powercap files present", "err", err)
+		return ErrNoData
+	}
 	zones, err := sysfs.GetRaplZones(c.fs)
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to read RAPL zones: %w", err)
 	}
 
 	for _, rz := range zones {
@@ -72,6 +83,20 @@ func (c *raplCollector) Update(ch chan<- prometheus.Metric) error {
 			float64(newMicrojoules)/1000000.0,
 			index,
 		)
+
+		// The energy counter wraps around at this value.
+		maxDescriptor := prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rapl", rz.Name+"_max_joules"),
+			"Value in joules at which the RAPL "+rz.Name+" counter wraps around",
+			[]string{"index"}, nil,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			maxDescriptor,
+			prometheus.GaugeValue,
+			float64(rz.MaxMicrojoules)/1000000.0,
+			index,
+		)
 	}
 	return nil
 }
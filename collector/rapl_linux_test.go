@@ -0,0 +1,60 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !norapl
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestRaplMissingPowercap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rapl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.sysfs", dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	c, err := NewRaplCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric, 10)
+	if err := c.Update(ch); err != ErrNoData {
+		t.Errorf("want ErrNoData without powercap class, got %v", err)
+	}
+
+	// A powercap class that can't be read is an error.
+	if err := os.MkdirAll(filepath.Join(dir, "class"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "class", "powercap"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(ch); err == nil || err == ErrNoData {
+		t.Errorf("want an error for an unreadable powercap class, got %v", err)
+	}
+}
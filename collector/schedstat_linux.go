@@ -16,9 +16,12 @@
 package collector
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -28,28 +31,9 @@ import (
 
 const nsPerSec = 1e9
 
-var (
-	runningSecondsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "schedstat", "running_seconds_total"),
-		"Number of seconds CPU spent running a process.",
-		[]string{"cpu"},
-		nil,
-	)
-
-	waitingSecondsTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "schedstat", "waiting_seconds_total"),
-		"Number of seconds spent by processing waiting for this CPU.",
-		[]string{"cpu"},
-		nil,
-	)
-
-	timeslicesTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "schedstat", "timeslices_total"),
-		"Number of timeslices executed by CPU.",
-		[]string{"cpu"},
-		nil,
-	)
-)
+// schedstatVersions are the /proc/schedstat versions whose cpu lines can be
+// parsed. Newer versions only changed the domain lines.
+var schedstatVersions = map[string]bool{"15": true, "16": true, "17": true}
 
 // NewSchedstatCollector returns a new Collector exposing task scheduler statistics
 func NewSchedstatCollector(logger log.Logger) (Collector, error) {
@@ -58,12 +42,36 @@ func NewSchedstatCollector(logger log.Logger) (Collector, error) {
 		return nil, fmt.Errorf("failed to open procfs: %w", err)
 	}
 
-	return &schedstatCollector{fs, logger}, nil
+	return &schedstatCollector{
+		fs: fs,
+		runningSecondsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "schedstat", "running_seconds_total"),
+			"Number of seconds CPU spent running a process.",
+			[]string{"cpu"},
+			nil,
+		),
+		waitingSecondsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "schedstat", "waiting_seconds_total"),
+			"Number of seconds spent by processing waiting for this CPU.",
+			[]string{"cpu"},
+			nil,
+		),
+		timeslicesTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "schedstat", "timeslices_total"),
+			"Number of timeslices executed by CPU.",
+			[]string{"cpu"},
+			nil,
+		),
+		logger: logger,
+	}, nil
 }
 
 type schedstatCollector struct {
-	fs     procfs.FS
-	logger log.Logger
+	fs                  procfs.FS
+	runningSecondsTotal *prometheus.Desc
+	waitingSecondsTotal *prometheus.Desc
+	timeslicesTotal     *prometheus.Desc
+	logger              log.Logger
 }
 
 func init() {
@@ -71,6 +79,19 @@ func init() {
 }
 
 func (c *schedstatCollector) Update(ch chan<- prometheus.Metric) error {
+	version, err := getSchedstatVersion()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "schedstat file does not exist")
+			return ErrNoData
+		}
+		return err
+	}
+	if !schedstatVersions[version] {
+		level.Debug(c.logger).Log("msg", "unsupported schedstat version", "version", version)
+		return ErrNoData
+	}
+
 	stats, err := c.fs.Schedstat()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -82,21 +103,21 @@ func (c *schedstatCollector) Update(ch chan<- prometheus.Metric) error {
 
 	for _, cpu := range stats.CPUs {
 		ch <- prometheus.MustNewConstMetric(
-			runningSecondsTotal,
+			c.runningSecondsTotal,
 			prometheus.CounterValue,
 			float64(cpu.RunningNanoseconds)/nsPerSec,
 			cpu.CPUNum,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			waitingSecondsTotal,
+			c.waitingSecondsTotal,
 			prometheus.CounterValue,
 			float64(cpu.WaitingNanoseconds)/nsPerSec,
 			cpu.CPUNum,
 		)
 
 		ch <- prometheus.MustNewConstMetric(
-			timeslicesTotal,
+			c.timeslicesTotal,
 			prometheus.CounterValue,
 			float64(cpu.RunTimeslices),
 			cpu.CPUNum,
@@ -105,3 +126,29 @@ func (c *schedstatCollector) Update(ch chan<- prometheus.Metric) error {
 
 	return nil
 }
+
+func getSchedstatVersion() (string, error) {
+	file, err := os.Open(procFilePath("schedstat"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return parseSchedstatVersion(file)
+}
+
+// parseSchedstatVersion returns the version from the "version N" header line.
+func parseSchedstatVersion(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", errors.New("schedstat empty")
+	}
+	parts := strings.Fields(scanner.Text())
+	if len(parts) != 2 || parts[0] != "version" {
+		return "", fmt.Errorf("invalid schedstat header: %q", scanner.Text())
+	}
+	return parts[1], nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noshedstat
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSchedstatVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		in        string
+		version   string
+		supported bool
+		err       bool
+	}{
+		{name: "supported", in: "version 15\ntimestamp 15819019232\n", version: "15", supported: true},
+		{name: "newest supported", in: "version 17\n", version: "17", supported: true},
+		{name: "unsupported", in: "version 14\ntimestamp 4294990600\n", version: "14"},
+		{name: "empty", in: "", err: true},
+		{name: "no version line", in: "timestamp 15819019232\n", err: true},
+		{name: "missing version", in: "version\n", err: true},
+		{name: "extra fields", in: "version 15 16\n", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := parseSchedstatVersion(strings.NewReader(tc.in))
+			if tc.err {
+				if err == nil {
+					t.Fatalf("want an error, got version %q", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != tc.version {
+				t.Errorf("want version %q, got %q", tc.version, version)
+			}
+			if schedstatVersions[version] != tc.supported {
+				t.Errorf("want supported %v for version %q", tc.supported, version)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noslabinfo
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const slabSubsystem = "slab"
+
+var (
+	slabNamesInclude = kingpin.Flag("collector.slabinfo.names-include", "Regexp of slab caches to include in the slabinfo collector.").Default("^(dentry|inode_cache|kmalloc-.*)$").String()
+)
+
+// slabCache is a line of /proc/slabinfo.
+type slabCache struct {
+	name          string
+	activeObjects float64
+	objectSize    float64
+	pages         float64
+}
+
+type slabinfoCollector struct {
+	namePattern *regexp.Regexp
+	objects     typedDesc
+	objectSize  typedDesc
+	pages       typedDesc
+	logger      log.Logger
+}
+
+func init() {
+	registerCollector("slabinfo", defaultDisabled, NewSlabinfoCollector)
+}
+
+// NewSlabinfoCollector returns a new Collector exposing the slab caches of
+// /proc/slabinfo matching --collector.slabinfo.names-include.
+func NewSlabinfoCollector(logger log.Logger) (Collector, error) {
+	return &slabinfoCollector{
+		namePattern: regexp.MustCompile(*slabNamesInclude),
+		objects: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, slabSubsystem, "objects"),
+			"Number of allocated objects of the slab cache.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		objectSize: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, slabSubsystem, "object_size_bytes"),
+			"Size of an object of the slab cache.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		pages: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, slabSubsystem, "pages"),
+			"Number of pages used by the slabs of the slab cache.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *slabinfoCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("slabinfo"))
+	if err != nil {
+		// /proc/slabinfo is only readable by root.
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			level.Debug(c.logger).Log("msg", "Can't read slabinfo", "err", err)
+			return ErrNoData
+		}
+		return err
+	}
+	defer file.Close()
+
+	caches, err := parseSlabinfo(file, c.namePattern)
+	if err != nil {
+		return fmt.Errorf("couldn't parse slabinfo: %w", err)
+	}
+	for _, s := range caches {
+		ch <- c.objects.mustNewConstMetric(s.activeObjects, s.name)
+		ch <- c.objectSize.mustNewConstMetric(s.objectSize, s.name)
+		ch <- c.pages.mustNewConstMetric(s.pages, s.name)
+	}
+	return nil
+}
+
+// parseSlabinfo returns the slab caches matching pattern of a slabinfo 2.x
+// file, with lines like:
+//
+//	# name <active_objs> <num_objs> <objsize> <objperslab> <pagesperslab> : tunables ... : slabdata <active_slabs> <num_slabs> <sharedavail>
+func parseSlabinfo(r io.Reader, pattern *regexp.Regexp) ([]slabCache, error) {
+	var (
+		caches  []slabCache
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "slabinfo -") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 16 || fields[6] != ":" || fields[11] != ":" {
+			return nil, fmt.Errorf("invalid line in slabinfo: %q", line)
+		}
+		if !pattern.MatchString(fields[0]) {
+			continue
+		}
+		var values [16]float64
+		for _, i := range []int{1, 3, 5, 14} {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in slabinfo: %w", fields[i], err)
+			}
+			values[i] = v
+		}
+		caches = append(caches, slabCache{
+			name:          fields[0],
+			activeObjects: values[1],
+			objectSize:    values[3],
+			pages:         values[5] * values[14],
+		})
+	}
+	return caches, scanner.Err()
+}
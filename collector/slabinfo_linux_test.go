@@ -0,0 +1,45 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noslabinfo
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseSlabinfo(t *testing.T) {
+	file, err := os.Open("fixtures/proc/slabinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	caches, err := parseSlabinfo(file, regexp.MustCompile(*slabNamesInclude))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []slabCache{
+		{name: "inode_cache", activeObjects: 13610, objectSize: 632, pages: 2720},
+		{name: "dentry", activeObjects: 94199, objectSize: 192, pages: 4536},
+		{name: "kmalloc-8k", activeObjects: 196, objectSize: 8192, pages: 416},
+		{name: "kmalloc-64", activeObjects: 12133, objectSize: 64, pages: 195},
+	}
+	if !reflect.DeepEqual(caches, want) {
+		t.Errorf("want %+v, got %+v", want, caches)
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosmart
+
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const smartSubsystem = "smart"
+
+// Constants from scsi/sg.h and the ATA command set.
+const (
+	sgIO           = 0x2285
+	sgDxferNone    = -1
+	sgDxferFromDev = -3
+	sgInterfaceID  = 'S'
+
+	ataPassThrough16   = 0x85
+	ataProtocolNonData = 3
+	ataProtocolPIOIn   = 4
+	ataCheckCondition  = 0x20
+	ataCheckPowerMode  = 0xe5
+	ataSmart           = 0xb0
+	ataSmartReadValue  = 0xd0
+	ataSmartLBAMid     = 0x4f
+	ataSmartLBAHigh    = 0xc2
+
+	// The ATA Status Return sense data descriptor, which holds the ATA
+	// registers after a command with the CK_COND bit.
+	senseDescriptorFormat = 0x72
+	senseATAStatusReturn  = 0x09
+	senseDescriptorStart  = 8
+
+	smartDataLength      = 512
+	smartAttributeLength = 12
+	smartAttributeCount  = 30
+	smartTimeoutMs       = 5000
+)
+
+var (
+	// errSmartUnsupported is returned for devices that don't answer the ATA
+	// SMART READ DATA command, like virtual disks.
+	errSmartUnsupported = errors.New("SMART not supported")
+	// errSmartStandby is returned for devices in standby, which reading the
+	// SMART data would spin up.
+	errSmartStandby = errors.New("device in standby")
+)
+
+// smartAttributeNames are the common names of vendor specific SMART
+// attributes, as used by smartctl.
+var smartAttributeNames = map[uint8]string{
+	1:   "raw_read_error_rate",
+	3:   "spin_up_time",
+	4:   "start_stop_count",
+	5:   "reallocated_sector_ct",
+	7:   "seek_error_rate",
+	9:   "power_on_hours",
+	10:  "spin_retry_count",
+	12:  "power_cycle_count",
+	184: "end_to_end_error",
+	187: "reported_uncorrect",
+	188: "command_timeout",
+	190: "airflow_temperature_cel",
+	192: "power_off_retract_count",
+	193: "load_cycle_count",
+	194: "temperature_celsius",
+	196: "reallocated_event_count",
+	197: "current_pending_sector",
+	198: "offline_uncorrectable",
+	199: "udma_crc_error_count",
+}
+
+// sgIOHdr is struct sg_io_hdr from scsi/sg.h.
+type sgIOHdr struct {
+	interfaceID    int32
+	dxferDirection int32
+	cmdLen         uint8
+	mxSbLen        uint8
+	iovecCount     uint16
+	dxferLen       uint32
+	dxferp         uintptr
+	cmdp           uintptr
+	sbp            uintptr
+	timeout        uint32
+	flags          uint32
+	packID         int32
+	usrPtr         uintptr
+	status         uint8
+	maskedStatus   uint8
+	msgStatus      uint8
+	sbLenWr        uint8
+	hostStatus     uint16
+	driverStatus   uint16
+	resid          int32
+	duration       uint32
+	info           uint32
+}
+
+type smartAttribute struct {
+	id    uint8
+	value uint8
+	raw   uint64
+}
+
+type smartCollector struct {
+	// readData reads the SMART data of the device, it is replaced in tests.
+	readData func(device string) ([]byte, error)
+
+	value, raw, temperature typedDesc
+	logger                  log.Logger
+}
+
+func init() {
+	registerCollector(smartSubsystem, defaultDisabled, NewSmartCollector)
+}
+
+// NewSmartCollector returns a new Collector exposing the SMART attributes of
+// ATA disks.
+func NewSmartCollector(logger log.Logger) (Collector, error) {
+	return &smartCollector{
+		readData: readSmartData,
+		value: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, smartSubsystem, "attribute_value"),
+			"Normalized value of the SMART attribute.",
+			[]string{"device", "attribute", "id"}, nil,
+		), prometheus.GaugeValue},
+		raw: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, smartSubsystem, "attribute_raw_value"),
+			"Raw value of the SMART attribute, its meaning is vendor specific.",
+			[]string{"device", "attribute", "id"}, nil,
+		), prometheus.GaugeValue},
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, smartSubsystem, "device_temperature_celsius"),
+			"Temperature of the device according to SMART.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *smartCollector) Update(ch chan<- prometheus.Metric) error {
+	devices, err := smartDevices()
+	if err != nil {
+		return fmt.Errorf("couldn't get block devices: %w", err)
+	}
+
+	var found bool
+	for _, device := range devices {
+		data, err := c.readData(device)
+		if errors.Is(err, errSmartStandby) {
+			level.Debug(c.logger).Log("msg", "Device is in standby or sleep, skipping", "device", device, "err", err)
+			continue
+		}
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't read SMART data, skipping", "device", device, "err", err)
+			continue
+		}
+		attributes, err := parseSmartData(data)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Invalid SMART data, skipping", "device", device, "err", err)
+			continue
+		}
+		found = true
+
+		temperature := -1.0
+		for _, a := range attributes {
+			name, ok := smartAttributeNames[a.id]
+			if !ok {
+				name = "unknown"
+			}
+			id := strconv.Itoa(int(a.id))
+			ch <- c.value.mustNewConstMetric(float64(a.value), device, name, id)
+			ch <- c.raw.mustNewConstMetric(float64(a.raw), device, name, id)
+
+			// The lowest byte of the raw value is the current temperature,
+			// the others may hold the minimum and maximum.
+			if a.id == 194 || (a.id == 190 && temperature < 0) {
+				temperature = float64(a.raw & 0xff)
+			}
+		}
+		if temperature >= 0 {
+			ch <- c.temperature.mustNewConstMetric(temperature, device)
+		}
+	}
+
+	if !found {
+		level.Debug(c.logger).Log("msg", "No devices with SMART support found")
+		return ErrNoData
+	}
+	return nil
+}
+
+// smartDevices returns the block devices backed by hardware. NVMe devices are
+// left to the nvme collector.
+func smartDevices() ([]string, error) {
+	entries, err := ioutil.ReadDir(sysFilePath("block"))
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, "nvme") {
+			continue
+		}
+		if _, err := os.Stat(sysFilePath("block/" + name + "/device")); err != nil {
+			continue
+		}
+		devices = append(devices, name)
+	}
+	return devices, nil
+}
+
+// readSmartData sends ATA SMART READ DATA to the device through an ATA
+// PASS-THROUGH (16) SCSI command with the SG_IO ioctl. Devices in standby are
+// left alone, as are sleeping devices, which don't answer CHECK POWER MODE.
+func readSmartData(device string) ([]byte, error) {
+	f, err := os.OpenFile(rootfsFilePath("/dev/"+device), os.O_RDONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sense [32]byte
+	cdb := [16]byte{
+		0:  ataPassThrough16,
+		1:  ataProtocolNonData << 1,
+		2:  ataCheckCondition,
+		14: ataCheckPowerMode,
+	}
+	hdr, err := sgIOCommand(f, cdb, nil, sense[:])
+	if errors.Is(err, errSmartUnsupported) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: CHECK POWER MODE failed: %v", errSmartStandby, err)
+	}
+	if hdr.hostStatus != 0 {
+		return nil, fmt.Errorf("%w: CHECK POWER MODE failed with host status %d", errSmartStandby, hdr.hostStatus)
+	}
+	mode, err := parsePowerMode(sense[:hdr.sbLenWr])
+	if err != nil {
+		return nil, err
+	}
+	if smartPowerModeStandby(mode) {
+		return nil, fmt.Errorf("%w: power mode %#x", errSmartStandby, mode)
+	}
+
+	cdb = [16]byte{
+		0:  ataPassThrough16,
+		1:  ataProtocolPIOIn << 1,
+		2:  0x0e, // Transfer from the device, in blocks given by the sector count.
+		4:  ataSmartReadValue,
+		6:  1,
+		10: ataSmartLBAMid,
+		12: ataSmartLBAHigh,
+		14: ataSmart,
+	}
+	data := make([]byte, smartDataLength)
+	hdr, err = sgIOCommand(f, cdb, data, sense[:])
+	if err != nil {
+		return nil, err
+	}
+	if hdr.status != 0 || hdr.hostStatus != 0 || hdr.driverStatus != 0 {
+		return nil, errSmartUnsupported
+	}
+	return data, nil
+}
+
+// sgIOCommand runs the SCSI command cdb on f, reading into data if it isn't
+// empty.
+func sgIOCommand(f *os.File, cdb [16]byte, data, sense []byte) (sgIOHdr, error) {
+	hdr := sgIOHdr{
+		interfaceID:    sgInterfaceID,
+		dxferDirection: sgDxferNone,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		sbp:            uintptr(unsafe.Pointer(&sense[0])),
+		timeout:        smartTimeoutMs,
+	}
+	if len(data) > 0 {
+		hdr.dxferDirection = sgDxferFromDev
+		hdr.dxferLen = uint32(len(data))
+		hdr.dxferp = uintptr(unsafe.Pointer(&data[0]))
+	}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), sgIO, uintptr(unsafe.Pointer(&hdr)))
+	runtime.KeepAlive(data)
+	runtime.KeepAlive(&cdb)
+	runtime.KeepAlive(sense)
+	if errno != 0 {
+		if errno == unix.ENOTTY || errno == unix.EINVAL {
+			return hdr, errSmartUnsupported
+		}
+		return hdr, errno
+	}
+	return hdr, nil
+}
+
+// parsePowerMode returns the power mode in the sector count register of the
+// ATA Status Return descriptor of the sense data of CHECK POWER MODE.
+func parsePowerMode(sense []byte) (uint8, error) {
+	if len(sense) < senseDescriptorStart+14 || sense[0]&0x7f != senseDescriptorFormat || sense[senseDescriptorStart] != senseATAStatusReturn {
+		return 0, fmt.Errorf("%w: no ATA status in CHECK POWER MODE sense data", errSmartUnsupported)
+	}
+	return sense[senseDescriptorStart+5], nil
+}
+
+// smartPowerModeStandby returns whether the CHECK POWER MODE result is one of
+// the standby modes, 0xff is active or idle and 0x80 to 0x83 are idle modes.
+func smartPowerModeStandby(mode uint8) bool {
+	return mode == 0x00 || mode == 0x01
+}
+
+// parseSmartData returns the attributes of the SMART data structure, which
+// also contains a checksum rejecting garbage from devices that accepted the
+// command without supporting it.
+func parseSmartData(b []byte) ([]smartAttribute, error) {
+	if len(b) < smartDataLength {
+		return nil, fmt.Errorf("SMART data too short: %d bytes", len(b))
+	}
+	var sum uint8
+	for _, v := range b[:smartDataLength] {
+		sum += v
+	}
+	if sum != 0 {
+		return nil, errSmartUnsupported
+	}
+
+	var attributes []smartAttribute
+	for i := 0; i < smartAttributeCount; i++ {
+		a := b[2+i*smartAttributeLength : 2+(i+1)*smartAttributeLength]
+		if a[0] == 0 {
+			continue
+		}
+		var raw [8]byte
+		copy(raw[:], a[5:11])
+		attributes = append(attributes, smartAttribute{
+			id:    a[0],
+			value: a[3],
+			raw:   binary.LittleEndian.Uint64(raw[:]),
+		})
+	}
+	if len(attributes) == 0 {
+		return nil, errSmartUnsupported
+	}
+	return attributes, nil
+}
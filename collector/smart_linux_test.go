@@ -0,0 +1,177 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosmart
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// smartTestData returns SMART data with the given attributes, each being
+// id, normalized value and raw value.
+func smartTestData(attributes ...[3]uint64) []byte {
+	b := make([]byte, smartDataLength)
+	for i, a := range attributes {
+		off := 2 + i*smartAttributeLength
+		b[off] = uint8(a[0])
+		b[off+3] = uint8(a[1])
+		for j := 0; j < 6; j++ {
+			b[off+5+j] = uint8(a[2] >> (8 * j))
+		}
+	}
+	var sum uint8
+	for _, v := range b[:smartDataLength-1] {
+		sum += v
+	}
+	b[smartDataLength-1] = -sum
+	return b
+}
+
+func TestParseSmartData(t *testing.T) {
+	attributes, err := parseSmartData(smartTestData([3]uint64{5, 100, 8}, [3]uint64{194, 64, 0x2a0012002a}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []smartAttribute{{id: 5, value: 100, raw: 8}, {id: 194, value: 64, raw: 0x2a0012002a}}
+	if len(attributes) != len(want) {
+		t.Fatalf("want %d attributes, got %d", len(want), len(attributes))
+	}
+	for i := range want {
+		if attributes[i] != want[i] {
+			t.Errorf("want %+v, got %+v", want[i], attributes[i])
+		}
+	}
+
+	b := smartTestData([3]uint64{5, 100, 8})
+	b[100]++
+	if _, err := parseSmartData(b); err != errSmartUnsupported {
+		t.Errorf("want errSmartUnsupported for a bad checksum, got %v", err)
+	}
+	if _, err := parseSmartData(make([]byte, smartDataLength)); err != errSmartUnsupported {
+		t.Errorf("want errSmartUnsupported without attributes, got %v", err)
+	}
+}
+
+func TestParsePowerMode(t *testing.T) {
+	// statusReturn is descriptor format sense data with an ATA Status Return
+	// descriptor holding the sector count.
+	statusReturn := func(count byte) []byte {
+		sense := make([]byte, 22)
+		sense[0], sense[7] = 0x72, 14
+		sense[8], sense[9], sense[13] = 0x09, 0x0c, count
+		return sense
+	}
+	for _, tc := range []struct {
+		name    string
+		sense   []byte
+		mode    uint8
+		standby bool
+		err     bool
+	}{
+		{name: "standby", sense: statusReturn(0x00), mode: 0x00, standby: true},
+		{name: "idle", sense: statusReturn(0x80), mode: 0x80},
+		{name: "active", sense: statusReturn(0xff), mode: 0xff},
+		{name: "fixed format", sense: append([]byte{0x70}, make([]byte, 21)...), err: true},
+		{name: "short", sense: statusReturn(0xff)[:12], err: true},
+		{name: "empty", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mode, err := parsePowerMode(tc.sense)
+			if tc.err {
+				if !errors.Is(err, errSmartUnsupported) {
+					t.Fatalf("want errSmartUnsupported, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tc.mode {
+				t.Errorf("want mode %#x, got %#x", tc.mode, mode)
+			}
+			if got := smartPowerModeStandby(mode); got != tc.standby {
+				t.Errorf("want standby %v, got %v", tc.standby, got)
+			}
+		})
+	}
+}
+
+func TestSmartCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"block/sda/device", "block/sdb/device", "block/vda/device", "block/loop0", "block/nvme0n1/device"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.sysfs", dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewSmartCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := c.(*smartCollector)
+	sc.readData = func(device string) ([]byte, error) {
+		switch device {
+		case "sda":
+		case "sdb":
+			return nil, fmt.Errorf("%w: power mode 0x0", errSmartStandby)
+		default:
+			return nil, errSmartUnsupported
+		}
+		return smartTestData([3]uint64{5, 100, 8}, [3]uint64{194, 64, 0x2a0012002a}, [3]uint64{231, 100, 0}), nil
+	}
+
+	want := `# HELP node_smart_attribute_raw_value Raw value of the SMART attribute, its meaning is vendor specific.
+# TYPE node_smart_attribute_raw_value gauge
+node_smart_attribute_raw_value{attribute="reallocated_sector_ct",device="sda",id="5"} 8
+node_smart_attribute_raw_value{attribute="temperature_celsius",device="sda",id="194"} 1.80389806122e+11
+node_smart_attribute_raw_value{attribute="unknown",device="sda",id="231"} 0
+# HELP node_smart_attribute_value Normalized value of the SMART attribute.
+# TYPE node_smart_attribute_value gauge
+node_smart_attribute_value{attribute="reallocated_sector_ct",device="sda",id="5"} 100
+node_smart_attribute_value{attribute="temperature_celsius",device="sda",id="194"} 64
+node_smart_attribute_value{attribute="unknown",device="sda",id="231"} 100
+# HELP node_smart_device_temperature_celsius Temperature of the device according to SMART.
+# TYPE node_smart_device_temperature_celsius gauge
+node_smart_device_temperature_celsius{device="sda"} 42
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	sc.readData = func(string) ([]byte, error) {
+		return nil, errSmartUnsupported
+	}
+	if err := c.Update(make(chan prometheus.Metric, 10)); err != ErrNoData {
+		t.Fatalf("want ErrNoData without SMART devices, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosysctl
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	sysctlInclude = kingpin.Flag("collector.sysctl.include", "Sysctl to expose, like net.ipv4.tcp_rmem or net/ipv4/tcp_rmem (repeatable).").Strings()
+)
+
+type sysctlCollector struct {
+	sysctls []string
+	desc    typedDesc
+	logger  log.Logger
+}
+
+func init() {
+	registerCollector("sysctl", defaultDisabled, NewSysctlCollector)
+}
+
+// NewSysctlCollector returns a new Collector exposing the numeric sysctls
+// given by --collector.sysctl.include.
+func NewSysctlCollector(logger log.Logger) (Collector, error) {
+	sysctls := make([]string, 0, len(*sysctlInclude))
+	seen := map[string]bool{}
+	for _, name := range *sysctlInclude {
+		// In slash notation dots are part of names, like in
+		// net/ipv4/conf/eth0.100/rp_filter.
+		path := name
+		if !strings.Contains(path, "/") {
+			path = strings.Replace(path, ".", "/", -1)
+		}
+		path = strings.Trim(path, "/")
+		if path == "" || strings.Contains(path, "..") {
+			return nil, fmt.Errorf("invalid --collector.sysctl.include %q", name)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		// Sysctls which can't be read yet, like the ones of interfaces
+		// which aren't up, might show up later while strings never turn
+		// into numbers.
+		if _, err := readSysctl(path); errors.Is(err, errSysctlNonNumeric) {
+			level.Warn(logger).Log("msg", "Ignoring non-numeric sysctl", "name", name, "err", err)
+			continue
+		}
+		sysctls = append(sysctls, path)
+	}
+
+	return &sysctlCollector{
+		sysctls: sysctls,
+		desc: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "sysctl"),
+			"Value of a sysctl, index is the position of the value for sysctls with multiple values.",
+			[]string{"name", "index"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *sysctlCollector) Update(ch chan<- prometheus.Metric) error {
+	if len(c.sysctls) == 0 {
+		level.Debug(c.logger).Log("msg", "No --collector.sysctl.include configured, skipping")
+		return ErrNoData
+	}
+
+	for _, path := range c.sysctls {
+		name := strings.Replace(path, "/", ".", -1)
+		values, err := readSysctl(path)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Skipping sysctl", "name", name, "err", err)
+			continue
+		}
+		for i, v := range values {
+			ch <- c.desc.mustNewConstMetric(v, name, strconv.Itoa(i))
+		}
+	}
+	return nil
+}
+
+// errSysctlNonNumeric is returned by readSysctl for sysctls with a string
+// value, like kernel.osrelease.
+var errSysctlNonNumeric = errors.New("non-numeric value")
+
+// readSysctl returns the whitespace separated numbers of a sysctl.
+func readSysctl(path string) ([]float64, error) {
+	content, err := ioutil.ReadFile(procFilePath("sys/" + path))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return nil, errors.New("empty value")
+	}
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		if values[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return nil, fmt.Errorf("%w %q", errSysctlNonNumeric, f)
+		}
+	}
+	return values, nil
+}
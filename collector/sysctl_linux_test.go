@@ -0,0 +1,47 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosysctl
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestNewSysctlCollector(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{
+		"--path.procfs", "./fixtures/proc",
+		"--collector.sysctl.include", "kernel.pid_max",
+		"--collector.sysctl.include", "kernel/pid_max",
+		"--collector.sysctl.include", "kernel.osrelease",
+		"--collector.sysctl.include", "net.ipv4.conf.missing.rp_filter",
+		"--collector.sysctl.include", "net/ipv4/tcp_rmem",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	c, err := NewSysctlCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"kernel/pid_max", "net/ipv4/conf/missing/rp_filter", "net/ipv4/tcp_rmem"}
+	if got := c.(*sysctlCollector).sysctls; !reflect.DeepEqual(got, want) {
+		t.Errorf("want sysctls %v, got %v", want, got)
+	}
+}
@@ -46,7 +46,7 @@ var (
 	oldUnitExclude         = kingpin.Flag("collector.systemd.unit-blacklist", "DEPRECATED: Use collector.systemd.unit-exclude").Hidden().String()
 	systemdPrivate         = kingpin.Flag("collector.systemd.private", "Establish a private, direct connection to systemd without dbus (Strongly discouraged since it requires root. For testing purposes only).").Hidden().Bool()
 	enableTaskMetrics      = kingpin.Flag("collector.systemd.enable-task-metrics", "Enables service unit tasks metrics unit_tasks_current and unit_tasks_max").Bool()
-	enableRestartsMetrics  = kingpin.Flag("collector.systemd.enable-restarts-metrics", "Enables service unit metric service_restart_total").Bool()
+	enableRestartsMetrics  = kingpin.Flag("collector.systemd.enable-restarts-metrics", "Enables service unit metrics service_restart_total and unit_oom_killed").Bool()
 	enableStartTimeMetrics = kingpin.Flag("collector.systemd.enable-start-time-metrics", "Enables service unit metric unit_start_time_seconds").Bool()
 )
 
@@ -58,7 +58,9 @@ type systemdCollector struct {
 	systemRunningDesc             *prometheus.Desc
 	summaryDesc                   *prometheus.Desc
 	nRestartsDesc                 *prometheus.Desc
+	unitOOMKilledDesc             *prometheus.Desc
 	timerLastTriggerDesc          *prometheus.Desc
+	timerNextElapseDesc           *prometheus.Desc
 	socketAcceptedConnectionsDesc *prometheus.Desc
 	socketCurrentConnectionsDesc  *prometheus.Desc
 	socketRefusedConnectionsDesc  *prometheus.Desc
@@ -106,9 +108,15 @@ func NewSystemdCollector(logger log.Logger) (Collector, error) {
 	nRestartsDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, subsystem, "service_restart_total"),
 		"Service unit count of Restart triggers", []string{"name"}, nil)
+	unitOOMKilledDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "unit_oom_killed"),
+		"Whether the last run of the service unit was ended by the OOM killer", []string{"name"}, nil)
 	timerLastTriggerDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, subsystem, "timer_last_trigger_seconds"),
-		"Seconds since epoch of last trigger.", []string{"name"}, nil)
+		"Seconds since epoch of last trigger, absent if the timer never triggered.", []string{"name"}, nil)
+	timerNextElapseDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "timer_next_elapse_seconds"),
+		"Seconds since epoch of the next realtime trigger, absent if there is none.", []string{"name"}, nil)
 	socketAcceptedConnectionsDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, subsystem, "socket_accepted_connections_total"),
 		"Total number of accepted socket connections", []string{"name"}, nil)
@@ -157,7 +165,9 @@ func NewSystemdCollector(logger log.Logger) (Collector, error) {
 		systemRunningDesc:             systemRunningDesc,
 		summaryDesc:                   summaryDesc,
 		nRestartsDesc:                 nRestartsDesc,
+		unitOOMKilledDesc:             unitOOMKilledDesc,
 		timerLastTriggerDesc:          timerLastTriggerDesc,
+		timerNextElapseDesc:           timerNextElapseDesc,
 		socketAcceptedConnectionsDesc: socketAcceptedConnectionsDesc,
 		socketCurrentConnectionsDesc:  socketCurrentConnectionsDesc,
 		socketRefusedConnectionsDesc:  socketRefusedConnectionsDesc,
@@ -292,6 +302,18 @@ func (c *systemdCollector) collectUnitStatusMetrics(conn *dbus.Conn, ch chan<- p
 					c.nRestartsDesc, prometheus.CounterValue,
 					float64(restartsCount.Value.Value().(uint32)), unit.Name)
 			}
+			result, err := conn.GetUnitTypeProperty(unit.Name, "Service", "Result")
+			if err != nil {
+				level.Debug(c.logger).Log("msg", "couldn't get unit Result", "unit", unit.Name, "err", err)
+			} else {
+				oomKilled := 0.0
+				if result.Value.Value().(string) == "oom-kill" {
+					oomKilled = 1.0
+				}
+				ch <- prometheus.MustNewConstMetric(
+					c.unitOOMKilledDesc, prometheus.GaugeValue,
+					oomKilled, unit.Name)
+			}
 		}
 	}
 }
@@ -391,15 +413,29 @@ func (c *systemdCollector) collectTimers(conn *dbus.Conn, ch chan<- prometheus.M
 			continue
 		}
 
+		// systemd reports 0 for timers that never triggered and for timers
+		// without a realtime trigger, those metrics are omitted.
 		lastTriggerValue, err := conn.GetUnitTypeProperty(unit.Name, "Timer", "LastTriggerUSec")
 		if err != nil {
 			level.Debug(c.logger).Log("msg", "couldn't get unit LastTriggerUSec", "unit", unit.Name, "err", err)
 			continue
 		}
+		if lastTrigger := lastTriggerValue.Value.Value().(uint64); lastTrigger > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.timerLastTriggerDesc, prometheus.GaugeValue,
+				float64(lastTrigger)/1e6, unit.Name)
+		}
 
-		ch <- prometheus.MustNewConstMetric(
-			c.timerLastTriggerDesc, prometheus.GaugeValue,
-			float64(lastTriggerValue.Value.Value().(uint64))/1e6, unit.Name)
+		nextElapseValue, err := conn.GetUnitTypeProperty(unit.Name, "Timer", "NextElapseUSecRealtime")
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "couldn't get unit NextElapseUSecRealtime", "unit", unit.Name, "err", err)
+			continue
+		}
+		if nextElapse := nextElapseValue.Value.Value().(uint64); nextElapse > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				c.timerNextElapseDesc, prometheus.GaugeValue,
+				float64(nextElapse)/1e6, unit.Name)
+		}
 	}
 }
 
@@ -16,9 +16,9 @@
 package collector
 
 import (
+	"bufio"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -115,13 +115,16 @@ func getTCPStats(statsFile string) (map[tcpConnectionState]float64, error) {
 }
 
 func parseTCPStats(r io.Reader) (map[tcpConnectionState]float64, error) {
-	tcpStats := map[tcpConnectionState]float64{}
-	contents, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, line := range strings.Split(string(contents), "\n")[1:] {
+	var (
+		tcpStats = map[tcpConnectionState]float64{}
+		scanner  = bufio.NewScanner(r)
+	)
+
+	// Busy servers have huge connection tables, so don't read the whole
+	// file at once. The first line is a header.
+	scanner.Scan()
+	for scanner.Scan() {
+		line := scanner.Text()
 		parts := strings.Fields(line)
 		if len(parts) == 0 {
 			continue
@@ -153,10 +156,9 @@ func parseTCPStats(r io.Reader) (map[tcpConnectionState]float64, error) {
 		}
 
 		tcpStats[tcpConnectionState(st)]++
-
 	}
 
-	return tcpStats, nil
+	return tcpStats, scanner.Err()
 }
 
 func (st tcpConnectionState) String() string {
@@ -16,16 +16,20 @@
 package collector
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
@@ -34,19 +38,19 @@ import (
 
 var (
 	textFileDirectory = kingpin.Flag("collector.textfile.directory", "Directory to read text files with metrics from.").Default("").String()
-	mtimeDesc         = prometheus.NewDesc(
-		"node_textfile_mtime_seconds",
-		"Unixtime mtime of textfiles successfully read.",
-		[]string{"file"},
-		nil,
-	)
+	textFileMaxAge    = kingpin.Flag("collector.textfile.max-age", "Skip text files that weren't modified for longer than this, 0 disables the check.").Default("0s").Duration()
 )
 
 type textFileCollector struct {
-	path string
+	path   string
+	maxAge time.Duration
 	// Only set for testing to get predictable output.
-	mtime  *float64
-	logger log.Logger
+	mtime *float64
+
+	mtimeDesc       *prometheus.Desc
+	staleFilesDesc  *prometheus.Desc
+	scrapeErrorDesc *prometheus.Desc
+	logger          log.Logger
 }
 
 func init() {
@@ -56,14 +60,56 @@ func init() {
 // NewTextFileCollector returns a new Collector exposing metrics read from files
 // in the given textfile directory.
 func NewTextFileCollector(logger log.Logger) (Collector, error) {
-	c := &textFileCollector{
-		path:   *textFileDirectory,
+	return newTextFileCollector(*textFileDirectory, *textFileMaxAge, logger), nil
+}
+
+func newTextFileCollector(path string, maxAge time.Duration, logger log.Logger) *textFileCollector {
+	return &textFileCollector{
+		path:   path,
+		maxAge: maxAge,
+		mtimeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "textfile", "mtime_seconds"),
+			"Unixtime mtime of textfiles successfully read.",
+			[]string{"file"},
+			nil,
+		),
+		staleFilesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "textfile", "stale_files"),
+			"Number of text files skipped because they weren't modified within --collector.textfile.max-age",
+			nil, nil,
+		),
+		scrapeErrorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "textfile", "scrape_error"),
+			"1 if there was an error opening or reading a file, 0 otherwise",
+			nil, nil,
+		),
 		logger: logger,
 	}
-	return c, nil
 }
 
-func convertMetricFamily(metricFamily *dto.MetricFamily, ch chan<- prometheus.Metric, logger log.Logger) {
+// maxExemplarRunes is the OpenMetrics limit for the combined length of the
+// label names and values of an exemplar.
+const maxExemplarRunes = 128
+
+// metricWithExemplar attaches an exemplar to a counter. Only OpenMetrics
+// scrapes expose it, the text format drops it.
+type metricWithExemplar struct {
+	prometheus.Metric
+	exemplar *dto.Exemplar
+}
+
+// Write implements prometheus.Metric.
+func (m metricWithExemplar) Write(pb *dto.Metric) error {
+	if err := m.Metric.Write(pb); err != nil {
+		return err
+	}
+	if pb.Counter != nil {
+		pb.Counter.Exemplar = m.exemplar
+	}
+	return nil
+}
+
+func convertMetricFamily(metricFamily *dto.MetricFamily, exemplars map[string]*dto.Exemplar, ch chan<- prometheus.Metric, logger log.Logger) {
 	var valType prometheus.ValueType
 	var val float64
 
@@ -152,7 +198,7 @@ func convertMetricFamily(metricFamily *dto.MetricFamily, ch chan<- prometheus.Me
 			panic("unknown metric type")
 		}
 		if metricType == dto.MetricType_GAUGE || metricType == dto.MetricType_COUNTER || metricType == dto.MetricType_UNTYPED {
-			ch <- prometheus.MustNewConstMetric(
+			m := prometheus.MustNewConstMetric(
 				prometheus.NewDesc(
 					*metricFamily.Name,
 					metricFamily.GetHelp(),
@@ -160,6 +206,14 @@ func convertMetricFamily(metricFamily *dto.MetricFamily, ch chan<- prometheus.Me
 				),
 				valType, val, values...,
 			)
+			if e, ok := exemplars[seriesKey(metricFamily.GetName(), labels)]; ok {
+				if metricType == dto.MetricType_COUNTER {
+					m = metricWithExemplar{Metric: m, exemplar: e}
+				} else {
+					level.Debug(logger).Log("msg", "Ignoring exemplar of non-counter textfile collector metric", "metric", metricFamily.GetName())
+				}
+			}
+			ch <- m
 		}
 	}
 }
@@ -182,7 +236,7 @@ func (c *textFileCollector) exportMTimes(mtimes map[string]time.Time, ch chan<-
 		if c.mtime != nil {
 			mtime = *c.mtime
 		}
-		ch <- prometheus.MustNewConstMetric(mtimeDesc, prometheus.GaugeValue, mtime, filename)
+		ch <- prometheus.MustNewConstMetric(c.mtimeDesc, prometheus.GaugeValue, mtime, filename)
 	}
 }
 
@@ -198,11 +252,26 @@ func (c *textFileCollector) Update(ch chan<- prometheus.Metric) error {
 	}
 
 	mtimes := make(map[string]time.Time, len(files))
+	var stale float64
 	for _, f := range files {
 		if !strings.HasSuffix(f.Name(), ".prom") {
 			continue
 		}
 
+		if c.maxAge > 0 {
+			isStale, err := c.isStale(f.Name())
+			if err != nil {
+				errored = true
+				level.Error(c.logger).Log("msg", "failed to collect textfile data", "file", f.Name(), "err", err)
+				continue
+			}
+			if isStale {
+				stale++
+				level.Debug(c.logger).Log("msg", "Skipping stale textfile", "file", f.Name(), "max_age", c.maxAge)
+				continue
+			}
+		}
+
 		mtime, err := c.processFile(f.Name(), ch)
 		if err != nil {
 			errored = true
@@ -215,24 +284,31 @@ func (c *textFileCollector) Update(ch chan<- prometheus.Metric) error {
 
 	c.exportMTimes(mtimes, ch)
 
+	if c.maxAge > 0 {
+		ch <- prometheus.MustNewConstMetric(c.staleFilesDesc, prometheus.GaugeValue, stale)
+	}
+
 	// Export if there were errors.
 	var errVal float64
 	if errored {
 		errVal = 1.0
 	}
 
-	ch <- prometheus.MustNewConstMetric(
-		prometheus.NewDesc(
-			"node_textfile_scrape_error",
-			"1 if there was an error opening or reading a file, 0 otherwise",
-			nil, nil,
-		),
-		prometheus.GaugeValue, errVal,
-	)
+	ch <- prometheus.MustNewConstMetric(c.scrapeErrorDesc, prometheus.GaugeValue, errVal)
 
 	return nil
 }
 
+// isStale returns whether the file wasn't modified within the max age.
+func (c *textFileCollector) isStale(name string) (bool, error) {
+	path := filepath.Join(c.path, name)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+	return time.Since(stat.ModTime()) > c.maxAge, nil
+}
+
 // processFile processes a single file, returning its modification time on success.
 func (c *textFileCollector) processFile(name string, ch chan<- prometheus.Metric) (*time.Time, error) {
 	path := filepath.Join(c.path, name)
@@ -242,8 +318,17 @@ func (c *textFileCollector) processFile(name string, ch chan<- prometheus.Metric
 	}
 	defer f.Close()
 
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read textfile data file %q: %w", path, err)
+	}
+	data, exemplars, err := extractExemplars(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse exemplars from %q: %w", path, err)
+	}
+
 	var parser expfmt.TextParser
-	families, err := parser.TextToMetricFamilies(f)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse textfile data from %q: %w", path, err)
 	}
@@ -260,7 +345,7 @@ func (c *textFileCollector) processFile(name string, ch chan<- prometheus.Metric
 	}
 
 	for _, mf := range families {
-		convertMetricFamily(mf, ch, c.logger)
+		convertMetricFamily(mf, exemplars, ch, c.logger)
 	}
 
 	// Only stat the file once it has been parsed and validated, so that
@@ -285,3 +370,151 @@ func hasTimestamps(parsedFamilies map[string]*dto.MetricFamily) bool {
 	}
 	return false
 }
+
+// extractExemplars removes OpenMetrics exemplars like
+// `foo_total 3 # {trace_id="abc"} 1 1600000000.5` from the sample lines of data,
+// as the text format parser doesn't support them. The exemplars are returned
+// keyed by the seriesKey of their sample.
+func extractExemplars(data []byte) ([]byte, map[string]*dto.Exemplar, error) {
+	var (
+		out       bytes.Buffer
+		exemplars = map[string]*dto.Exemplar{}
+	)
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		sample, exemplar := splitExemplar(line)
+		if exemplar == "" {
+			out.WriteString(line)
+			continue
+		}
+
+		key, err := sampleSeriesKey(sample)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := parseExemplar(exemplar)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid exemplar %q: %w", exemplar, err)
+		}
+		exemplars[key] = e
+		out.WriteString(sample + "\n")
+	}
+	return out.Bytes(), exemplars, nil
+}
+
+// splitExemplar splits a sample line into the sample and its exemplar, which
+// is empty if there is none.
+func splitExemplar(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' {
+		return line, ""
+	}
+	end := strings.IndexAny(trimmed, "{ \t")
+	if end < 0 {
+		return line, ""
+	}
+	if trimmed[end] == '{' {
+		n := labelSetEnd(trimmed[end:])
+		if n < 0 {
+			return line, ""
+		}
+		end += n
+	}
+	i := strings.IndexByte(trimmed[end:], '#')
+	if i < 0 {
+		return line, ""
+	}
+	return strings.TrimSpace(trimmed[:end+i]), strings.TrimSpace(trimmed[end+i+1:])
+}
+
+// labelSetEnd returns the index after the closing brace of the label set s
+// starts with, or -1 if it isn't terminated.
+func labelSetEnd(s string) int {
+	var quoted, escaped bool
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = quoted
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == '}' && !quoted:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// sampleSeriesKey returns the seriesKey of a single sample line.
+func sampleSeriesKey(sample string) (string, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(sample + "\n"))
+	if err != nil {
+		return "", err
+	}
+	for name, mf := range families {
+		for _, m := range mf.Metric {
+			return seriesKey(name, m.GetLabel()), nil
+		}
+	}
+	return "", fmt.Errorf("no sample in %q", sample)
+}
+
+// seriesKey identifies a series by its name and labels, independent of the
+// order of the labels.
+func seriesKey(name string, labels []*dto.LabelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, l.GetName()+"\xff"+l.GetValue())
+	}
+	sort.Strings(pairs)
+	return name + "\xfe" + strings.Join(pairs, "\xfe")
+}
+
+// parseExemplar parses an exemplar of the form `{labels} value [timestamp]`.
+func parseExemplar(s string) (*dto.Exemplar, error) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, fmt.Errorf("missing label set")
+	}
+	end := labelSetEnd(s)
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated label set")
+	}
+
+	// Reuse the text format parser for the labels by turning them into a
+	// sample of a dummy metric.
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader("exemplar" + s[:end] + " 0\n"))
+	if err != nil {
+		return nil, err
+	}
+	e := &dto.Exemplar{Label: families["exemplar"].Metric[0].GetLabel()}
+	var runes int
+	for _, l := range e.Label {
+		runes += utf8.RuneCountInString(l.GetName()) + utf8.RuneCountInString(l.GetValue())
+	}
+	if runes > maxExemplarRunes {
+		return nil, fmt.Errorf("labels are longer than %d characters", maxExemplarRunes)
+	}
+
+	fields := strings.Fields(s[end:])
+	if len(fields) < 1 || len(fields) > 2 {
+		return nil, fmt.Errorf("expected a value and an optional timestamp")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	e.Value = &value
+	if len(fields) == 2 {
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		sec := int64(ts)
+		if e.Timestamp, err = ptypes.TimestampProto(time.Unix(sec, int64((ts-float64(sec))*1e9))); err != nil {
+			return nil, fmt.Errorf("invalid timestamp: %w", err)
+		}
+	}
+	return e, nil
+}
@@ -18,11 +18,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -91,15 +96,16 @@ func TestTextfileCollector(t *testing.T) {
 			path: "fixtures/textfile/summary_extra_dimension",
 			out:  "fixtures/textfile/summary_extra_dimension.out",
 		},
+		{
+			path: "fixtures/textfile/exemplars",
+			out:  "fixtures/textfile/exemplars.out",
+		},
 	}
 
 	for i, test := range tests {
 		mtime := 1.0
-		c := &textFileCollector{
-			path:   test.path,
-			mtime:  &mtime,
-			logger: log.NewNopLogger(),
-		}
+		c := newTextFileCollector(test.path, 0, log.NewNopLogger())
+		c.mtime = &mtime
 
 		// Suppress a log message about `nonexistent_path` not existing, this is
 		// expected and clutters the test output.
@@ -126,3 +132,84 @@ func TestTextfileCollector(t *testing.T) {
 		}
 	}
 }
+
+func TestTextfileCollectorExemplars(t *testing.T) {
+	mtime := 1.0
+	c := newTextFileCollector("fixtures/textfile/exemplars", 0, log.NewNopLogger())
+	c.mtime = &mtime
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectorAdapter{c})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	rw := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(rw, req)
+	got := rw.Body.String()
+
+	for _, want := range []string{
+		`requests_total{code="200",handler="/"} 19.0 # {trace_id="KOO5S4vxi0o"} 1.0 1.6000000005e+09` + "\n",
+		`requests_total{code="500",handler="/"} 2.0` + "\n",
+		`queue_length 4.0` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestParseExemplarErrors(t *testing.T) {
+	for _, s := range []string{
+		`trace_id="abc" 1`,
+		`{trace_id="abc} 1`,
+		`{trace_id="abc"}`,
+		`{trace_id="abc"} one`,
+		`{trace_id="abc"} 1 2 3`,
+		`{trace_id="` + strings.Repeat("a", 121) + `"} 1`,
+	} {
+		if _, err := parseExemplar(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestTextfileCollectorMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "textfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"fresh.prom": "fresh_metric 1\n",
+		"old.prom":   "old_metric 1\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.prom"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := 1.0
+	c := newTextFileCollector(dir, time.Hour, log.NewNopLogger())
+	c.mtime = &mtime
+
+	want := `# HELP fresh_metric Metric read from ` + filepath.Join(dir, "fresh.prom") + `
+# TYPE fresh_metric untyped
+fresh_metric 1
+# HELP node_textfile_mtime_seconds Unixtime mtime of textfiles successfully read.
+# TYPE node_textfile_mtime_seconds gauge
+node_textfile_mtime_seconds{file="fresh.prom"} 1
+# HELP node_textfile_scrape_error 1 if there was an error opening or reading a file, 0 otherwise
+# TYPE node_textfile_scrape_error gauge
+node_textfile_scrape_error 0
+# HELP node_textfile_stale_files Number of text files skipped because they weren't modified within --collector.textfile.max-age
+# TYPE node_textfile_stale_files gauge
+node_textfile_stale_files 1
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -21,21 +21,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var unameDesc = prometheus.NewDesc(
-	prometheus.BuildFQName(namespace, "uname", "info"),
-	"Labeled system information as provided by the uname system call.",
-	[]string{
-		"sysname",
-		"release",
-		"version",
-		"machine",
-		"nodename",
-		"domainname",
-	},
-	nil,
-)
-
 type unameCollector struct {
+	desc   *prometheus.Desc
 	logger log.Logger
 }
 type uname struct {
@@ -53,7 +40,22 @@ func init() {
 
 // NewUnameCollector returns new unameCollector.
 func newUnameCollector(logger log.Logger) (Collector, error) {
-	return &unameCollector{logger}, nil
+	return &unameCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "uname", "info"),
+			"Labeled system information as provided by the uname system call.",
+			[]string{
+				"sysname",
+				"release",
+				"version",
+				"machine",
+				"nodename",
+				"domainname",
+			},
+			nil,
+		),
+		logger: logger,
+	}, nil
 }
 
 func (c *unameCollector) Update(ch chan<- prometheus.Metric) error {
@@ -62,7 +64,7 @@ func (c *unameCollector) Update(ch chan<- prometheus.Metric) error {
 		return err
 	}
 
-	ch <- prometheus.MustNewConstMetric(unameDesc, prometheus.GaugeValue, 1,
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1,
 		uname.SysName,
 		uname.Release,
 		uname.Version,
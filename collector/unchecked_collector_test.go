@@ -0,0 +1,35 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// uncheckedCollector exposes a Collector to a prometheus.Registry without
+// describing its metrics up front, for comparing the output of collectors
+// with testutil.CollectAndCompare.
+type uncheckedCollector struct {
+	c Collector
+}
+
+func (u uncheckedCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (u uncheckedCollector) Collect(ch chan<- prometheus.Metric) {
+	if err := u.c.Update(ch); err != nil {
+		panic(fmt.Sprintf("failed to update collector: %v", err))
+	}
+}
@@ -47,7 +47,10 @@ func init() {
 
 // NewvmStatCollector returns a new Collector exposing vmstat stats.
 func NewvmStatCollector(logger log.Logger) (Collector, error) {
-	pattern := regexp.MustCompile(*vmStatFields)
+	pattern, err := regexp.Compile(*vmStatFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --collector.vmstat.fields: %w", err)
+	}
 	return &vmStatCollector{
 		fieldPattern: pattern,
 		logger:       logger,
@@ -0,0 +1,122 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowatchdog
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const watchdogSubsystem = "watchdog"
+
+// watchdogFiles maps the numeric attributes of a watchdog device to the
+// exposed metrics and their help. Which attributes exist depends on the
+// driver.
+var watchdogFiles = map[string][2]string{
+	"timeout":    {"timeout_seconds", "Timeout of the watchdog device."},
+	"pretimeout": {"pretimeout_seconds", "Time before the timeout at which the pretimeout action of the watchdog device is run."},
+	"timeleft":   {"timeleft_seconds", "Time left until the watchdog device resets the system."},
+	"bootstatus": {"bootstatus", "Status of the watchdog device at boot, see the WDIOF_* flags of linux/watchdog.h."},
+	"nowayout":   {"nowayout", "Whether the watchdog device can't be stopped once started."},
+}
+
+type watchdogCollector struct {
+	descs  map[string]*prometheus.Desc
+	active *prometheus.Desc
+	info   *prometheus.Desc
+	logger log.Logger
+}
+
+func init() {
+	registerCollector(watchdogSubsystem, defaultEnabled, NewWatchdogCollector)
+}
+
+// NewWatchdogCollector returns a new Collector exposing the status of the
+// watchdog devices from /sys/class/watchdog.
+func NewWatchdogCollector(logger log.Logger) (Collector, error) {
+	descs := make(map[string]*prometheus.Desc, len(watchdogFiles))
+	for file, metric := range watchdogFiles {
+		descs[file] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, watchdogSubsystem, metric[0]),
+			metric[1],
+			[]string{"device"}, nil,
+		)
+	}
+	return &watchdogCollector{
+		descs: descs,
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, watchdogSubsystem, "active"),
+			"Whether the watchdog device is started.",
+			[]string{"device"}, nil,
+		),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, watchdogSubsystem, "info"),
+			"Non-numeric data from /sys/class/watchdog/<device>, value is always 1.",
+			[]string{"device", "identity"}, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+func (c *watchdogCollector) Update(ch chan<- prometheus.Metric) error {
+	dir := sysFilePath("class/watchdog")
+	devices, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			level.Debug(c.logger).Log("msg", "No watchdog devices found, skipping")
+			return ErrNoData
+		}
+		return err
+	}
+	if len(devices) == 0 {
+		level.Debug(c.logger).Log("msg", "No watchdog devices found, skipping")
+		return ErrNoData
+	}
+
+	for _, device := range devices {
+		name := device.Name()
+		path := filepath.Join(dir, name)
+		for file, desc := range c.descs {
+			value, err := readUintFromFile(filepath.Join(path, file))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return fmt.Errorf("couldn't read %s of watchdog %s: %w", file, name, err)
+			}
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value), name)
+		}
+
+		if state, err := ioutil.ReadFile(filepath.Join(path, "state")); err == nil {
+			active := 0.0
+			if strings.TrimSpace(string(state)) == "active" {
+				active = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, active, name)
+		}
+		if identity, err := ioutil.ReadFile(filepath.Join(path, "identity")); err == nil {
+			ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, name, strings.TrimSpace(string(identity)))
+		}
+	}
+	return nil
+}
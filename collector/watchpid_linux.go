@@ -0,0 +1,179 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowatchpid
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const watchedProcessSubsystem = "watched_process"
+
+var (
+	watchpidPidfile = kingpin.Flag("collector.watchpid.pidfile", "Pid file of the process to watch.").Default("").String()
+	watchpidCmdline = kingpin.Flag("collector.watchpid.cmdline", "Regexp of the command line of the process to watch, the process with the lowest PID is used.").Default("").String()
+)
+
+type watchpidCollector struct {
+	fs      procfs.FS
+	pidfile string
+	cmdline *regexp.Regexp
+
+	up, openFDs, maxFDs, memory typedDesc
+	logger                      log.Logger
+}
+
+func init() {
+	registerCollector("watchpid", defaultDisabled, NewWatchPIDCollector)
+}
+
+// NewWatchPIDCollector returns a new Collector exposing the file descriptor
+// and memory usage of the process given by --collector.watchpid.pidfile or
+// --collector.watchpid.cmdline.
+func NewWatchPIDCollector(logger log.Logger) (Collector, error) {
+	fs, err := procfs.NewFS(*procPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open procfs: %w", err)
+	}
+	if *watchpidPidfile != "" && *watchpidCmdline != "" {
+		return nil, errors.New("only one of --collector.watchpid.pidfile and --collector.watchpid.cmdline can be set")
+	}
+	var cmdline *regexp.Regexp
+	if *watchpidCmdline != "" {
+		if cmdline, err = regexp.Compile(*watchpidCmdline); err != nil {
+			return nil, fmt.Errorf("invalid --collector.watchpid.cmdline: %w", err)
+		}
+	}
+
+	desc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, watchedProcessSubsystem, name),
+			help, nil, nil,
+		), prometheus.GaugeValue}
+	}
+	return &watchpidCollector{
+		fs:      fs,
+		pidfile: *watchpidPidfile,
+		cmdline: cmdline,
+		up:      desc("up", "Whether the watched process was found."),
+		openFDs: desc("open_fds", "Number of open file descriptors of the watched process."),
+		maxFDs:  desc("max_fds", "Limit of open file descriptors of the watched process."),
+		memory:  desc("memory_bytes", "Resident memory size of the watched process."),
+		logger:  logger,
+	}, nil
+}
+
+func (c *watchpidCollector) Update(ch chan<- prometheus.Metric) error {
+	if c.pidfile == "" && c.cmdline == nil {
+		level.Debug(c.logger).Log("msg", "No process to watch configured, skipping")
+		return ErrNoData
+	}
+
+	proc, err := c.findProcess()
+	if err == nil {
+		err = c.updateProcess(ch, proc)
+	}
+	if err != nil {
+		// The process may exit at any time, which must not fail the scrape.
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, errWatchedProcessNotFound) {
+			return err
+		}
+		level.Debug(c.logger).Log("msg", "Watched process not found", "err", err)
+		ch <- c.up.mustNewConstMetric(0)
+		return nil
+	}
+	ch <- c.up.mustNewConstMetric(1)
+	return nil
+}
+
+// errWatchedProcessNotFound is returned if no process matches
+// --collector.watchpid.cmdline or the pid file is empty.
+var errWatchedProcessNotFound = errors.New("no matching process")
+
+func (c *watchpidCollector) findProcess() (procfs.Proc, error) {
+	if c.pidfile != "" {
+		content, err := ioutil.ReadFile(rootfsFilePath(c.pidfile))
+		if err != nil {
+			return procfs.Proc{}, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return procfs.Proc{}, fmt.Errorf("%w: invalid pid file %s", errWatchedProcessNotFound, c.pidfile)
+		}
+		return c.fs.Proc(pid)
+	}
+
+	procs, err := c.fs.AllProcs()
+	if err != nil {
+		return procfs.Proc{}, fmt.Errorf("unable to list processes: %w", err)
+	}
+	sort.Sort(procs)
+	// The command line of the exporter itself contains the regexp.
+	selfPID := os.Getpid()
+	if self, err := c.fs.Self(); err == nil {
+		selfPID = self.PID
+	}
+	for _, proc := range procs {
+		if proc.PID == selfPID {
+			continue
+		}
+		cmdline, err := proc.CmdLine()
+		if err != nil {
+			continue
+		}
+		if c.cmdline.MatchString(strings.Join(cmdline, " ")) {
+			return proc, nil
+		}
+	}
+	return procfs.Proc{}, errWatchedProcessNotFound
+}
+
+// updateProcess exposes the metrics of proc, the up metric is sent by Update
+// once all files could be read.
+func (c *watchpidCollector) updateProcess(ch chan<- prometheus.Metric, proc procfs.Proc) error {
+	fds, err := proc.FileDescriptorsLen()
+	if err != nil {
+		return fmt.Errorf("unable to count file descriptors: %w", err)
+	}
+	limits, err := proc.NewLimits()
+	if err != nil {
+		return fmt.Errorf("unable to read limits: %w", err)
+	}
+	status, err := proc.NewStatus()
+	if err != nil {
+		return fmt.Errorf("unable to read status: %w", err)
+	}
+
+	ch <- c.openFDs.mustNewConstMetric(float64(fds))
+	// An unlimited number of open files is -1.
+	if limits.OpenFiles >= 0 {
+		ch <- c.maxFDs.mustNewConstMetric(float64(limits.OpenFiles))
+	}
+	ch <- c.memory.mustNewConstMetric(float64(status.VmRSS))
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowatchpid
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// writeWatchedProcess creates the files of a process with three open file
+// descriptors in the procfs at dir.
+func writeWatchedProcess(t *testing.T, dir, pid, cmdline string) {
+	files := map[string]string{
+		"cmdline": strings.Replace(cmdline, " ", "\x00", -1) + "\x00",
+		"limits": "Limit                     Soft Limit           Hard Limit           Units     \n" +
+			"Max open files            1024                 4096                 files     \n",
+		"status": "Name:\tmydaemon\nVmRSS:\t    2048 kB\n",
+		"fd/0":   "",
+		"fd/1":   "",
+		"fd/2":   "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, pid, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWatchPIDCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watchpid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Restore the default flags for the other tests.
+	defer kingpin.CommandLine.Parse([]string{})
+	// A later match with one file descriptor less, which must lose against
+	// the lowest PID.
+	writeWatchedProcess(t, dir, "420", "/usr/sbin/mydaemon --worker")
+	if err := os.Remove(filepath.Join(dir, "420", "fd", "2")); err != nil {
+		t.Fatal(err)
+	}
+	writeWatchedProcess(t, dir, "42", "/usr/sbin/mydaemon --foreground")
+	writeWatchedProcess(t, dir, "43", "/usr/sbin/other")
+	// The exporter itself has the lowest PID and the regexp in its command
+	// line.
+	writeWatchedProcess(t, dir, "7", "/usr/local/bin/node_exporter --collector.watchpid.cmdline=mydaemon")
+	if err := os.RemoveAll(filepath.Join(dir, "7", "fd")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("7", filepath.Join(dir, "self")); err != nil {
+		t.Fatal(err)
+	}
+	pidfile := filepath.Join(dir, "mydaemon.pid")
+	if err := ioutil.WriteFile(pidfile, []byte("42\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const up = `
+# HELP node_watched_process_max_fds Limit of open file descriptors of the watched process.
+# TYPE node_watched_process_max_fds gauge
+node_watched_process_max_fds 1024
+# HELP node_watched_process_memory_bytes Resident memory size of the watched process.
+# TYPE node_watched_process_memory_bytes gauge
+node_watched_process_memory_bytes 2.097152e+06
+# HELP node_watched_process_open_fds Number of open file descriptors of the watched process.
+# TYPE node_watched_process_open_fds gauge
+node_watched_process_open_fds 3
+# HELP node_watched_process_up Whether the watched process was found.
+# TYPE node_watched_process_up gauge
+node_watched_process_up 1
+`
+	const down = `
+# HELP node_watched_process_up Whether the watched process was found.
+# TYPE node_watched_process_up gauge
+node_watched_process_up 0
+`
+	for _, tc := range []struct {
+		name     string
+		flag     string
+		expected string
+	}{
+		{name: "cmdline", flag: "--collector.watchpid.cmdline=mydaemon", expected: up},
+		{name: "pidfile", flag: "--collector.watchpid.pidfile=" + pidfile, expected: up},
+		{name: "no match", flag: "--collector.watchpid.cmdline=^/usr/bin/", expected: down},
+		{name: "no pidfile", flag: "--collector.watchpid.pidfile=" + filepath.Join(dir, "missing.pid"), expected: down},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := kingpin.CommandLine.Parse([]string{"--path.procfs", dir, tc.flag}); err != nil {
+				t.Fatal(err)
+			}
+			c, err := NewWatchPIDCollector(log.NewNopLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(tc.expected)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
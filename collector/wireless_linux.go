@@ -0,0 +1,242 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowireless
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+// Constants from linux/wireless.h.
+const (
+	siocGIWRange = 0x8b0b
+	// iwRangeMaxQualOffset is the offset of max_qual.qual in struct
+	// iw_range, which is 568 bytes with wireless extensions 22.
+	iwRangeMaxQualOffset = 44
+	iwRangeSize          = 1024
+)
+
+// wirelessStats is a line of /proc/net/wireless. Signal and noise are nil if
+// the driver doesn't report them.
+type wirelessStats struct {
+	linkQuality float64
+	signal      *float64
+	noise       *float64
+}
+
+type wirelessCollector struct {
+	// maxQuality returns the maximum link quality of a device, it is
+	// replaced in tests.
+	maxQuality func(device string) (float64, error)
+
+	linkQuality, signal, noise, connected typedDesc
+	logger                                log.Logger
+}
+
+func init() {
+	registerCollector("wireless", defaultEnabled, NewWirelessCollector)
+}
+
+// NewWirelessCollector returns a new Collector exposing the link quality of
+// wireless interfaces from /proc/net/wireless.
+func NewWirelessCollector(logger log.Logger) (Collector, error) {
+	// The wifi collector already uses the wifi subsystem.
+	const subsystem = "wireless"
+
+	return &wirelessCollector{
+		maxQuality: wirelessMaxQuality,
+		linkQuality: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "link_quality_ratio"),
+			"Link quality of the wireless interface, relative to the maximum link quality of its driver.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		signal: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "signal_dbm"),
+			"Signal level of the wireless interface in dBm.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		noise: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "noise_dbm"),
+			"Noise level of the wireless interface in dBm.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		connected: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "station_connected"),
+			"Whether the wireless interface is connected to an access point.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		logger: logger,
+	}, nil
+}
+
+func (c *wirelessCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("net/wireless"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			level.Debug(c.logger).Log("msg", "No wireless extensions, skipping", "err", err)
+			return ErrNoData
+		}
+		return err
+	}
+	defer file.Close()
+
+	stats, err := parseWirelessStats(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse wireless stats: %w", err)
+	}
+
+	// Interfaces that aren't connected are missing from /proc/net/wireless,
+	// sysfs still knows about them.
+	devices, err := wirelessDevices()
+	if err != nil {
+		return fmt.Errorf("couldn't get wireless interfaces: %w", err)
+	}
+	for device := range stats {
+		devices[device] = struct{}{}
+	}
+	if len(devices) == 0 {
+		level.Debug(c.logger).Log("msg", "No wireless interfaces found")
+		return ErrNoData
+	}
+
+	for device := range devices {
+		s, ok := stats[device]
+		if !ok {
+			ch <- c.connected.mustNewConstMetric(0, device)
+			continue
+		}
+		ch <- c.connected.mustNewConstMetric(1, device)
+		// The range of the link quality is driver specific.
+		if max, err := c.maxQuality(device); err != nil {
+			level.Debug(c.logger).Log("msg", "Couldn't get maximum link quality", "device", device, "err", err)
+		} else if max > 0 {
+			ch <- c.linkQuality.mustNewConstMetric(s.linkQuality/max, device)
+		}
+		if s.signal != nil {
+			ch <- c.signal.mustNewConstMetric(*s.signal, device)
+		}
+		if s.noise != nil {
+			ch <- c.noise.mustNewConstMetric(*s.noise, device)
+		}
+	}
+	return nil
+}
+
+// iwreq is struct iwreq with a struct iw_point from linux/wireless.h, the
+// union is padded to its full size.
+type iwreq struct {
+	name    [unix.IFNAMSIZ]byte
+	pointer uintptr
+	length  uint16
+	flags   uint16
+	_       [12 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// wirelessMaxQuality returns the maximum link quality of the driver of a
+// device from the SIOCGIWRANGE ioctl, it is 0 if the driver doesn't know it.
+func wirelessMaxQuality(device string) (float64, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer unix.Close(fd)
+
+	var rng [iwRangeSize]byte
+	req := iwreq{
+		pointer: uintptr(unsafe.Pointer(&rng[0])),
+		length:  uint16(len(rng)),
+	}
+	copy(req.name[:unix.IFNAMSIZ-1], device)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), siocGIWRange, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(&rng)
+	if errno != 0 {
+		return 0, errno
+	}
+	return float64(rng[iwRangeMaxQualOffset]), nil
+}
+
+// wirelessDevices returns the interfaces in /sys/class/net with a wireless
+// directory.
+func wirelessDevices() (map[string]struct{}, error) {
+	devices := map[string]struct{}{}
+	entries, err := ioutil.ReadDir(sysFilePath("class/net"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return devices, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(sysFilePath("class/net/" + e.Name() + "/wireless")); err == nil {
+			devices[e.Name()] = struct{}{}
+		}
+	}
+	return devices, nil
+}
+
+func parseWirelessStats(r io.Reader) (map[string]wirelessStats, error) {
+	var (
+		stats   = map[string]wirelessStats{}
+		scanner = bufio.NewScanner(r)
+	)
+	// Skip the two header lines.
+	scanner.Scan()
+	scanner.Scan()
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line in net/wireless: %q", scanner.Text())
+		}
+		fields := strings.Fields(parts[1])
+		// Status, link quality, signal and noise level.
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("invalid line in net/wireless: %q", scanner.Text())
+		}
+
+		// A trailing dot marks values updated since the last read.
+		var values [3]float64
+		for i, f := range fields[1:4] {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(f, "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in net/wireless: %w", f, err)
+			}
+			values[i] = v
+		}
+		s := wirelessStats{linkQuality: values[0]}
+		// Levels of -256 dBm stand for values the driver doesn't know.
+		if values[1] > -256 {
+			s.signal = &values[1]
+		}
+		if values[2] > -256 {
+			s.noise = &values[2]
+		}
+		stats[strings.TrimSpace(parts[0])] = s
+	}
+	return stats, scanner.Err()
+}
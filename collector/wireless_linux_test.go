@@ -0,0 +1,142 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowireless
+
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/sys/unix"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestWirelessCollector(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--path.procfs", "fixtures/proc", "--path.sysfs", "fixtures/sys"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	c, err := NewWirelessCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cfg80211 drivers report a maximum of 70.
+	c.(*wirelessCollector).maxQuality = func(device string) (float64, error) {
+		if device != "wlan0" {
+			return 0, errors.New("no wireless extensions")
+		}
+		return 70, nil
+	}
+
+	want := `# HELP node_wireless_link_quality_ratio Link quality of the wireless interface, relative to the maximum link quality of its driver.
+# TYPE node_wireless_link_quality_ratio gauge
+node_wireless_link_quality_ratio{device="wlan0"} 0.7714285714285715
+# HELP node_wireless_signal_dbm Signal level of the wireless interface in dBm.
+# TYPE node_wireless_signal_dbm gauge
+node_wireless_signal_dbm{device="wlan0"} -56
+node_wireless_signal_dbm{device="wlp3s0"} -38
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want), "node_wireless_link_quality_ratio", "node_wireless_signal_dbm"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWirelessIwreqSize(t *testing.T) {
+	// struct iwreq is the interface name followed by a 16 byte union.
+	if got, want := unsafe.Sizeof(iwreq{}), uintptr(unix.IFNAMSIZ+16); got != want {
+		t.Errorf("sizeof(iwreq) = %d, want %d", got, want)
+	}
+}
+
+func TestParseWirelessStats(t *testing.T) {
+	const header = "Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE\n" +
+		" face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22\n"
+	level := func(v float64) *float64 { return &v }
+
+	for _, tc := range []struct {
+		name string
+		in   string
+		want map[string]wirelessStats
+		err  bool
+	}{
+		{
+			name: "interfaces",
+			in: header +
+				" wlan0: 0000   54.  -56.  -256        0      0      0      0      0        0\n" +
+				"wlp3s0: 0000   70.  -38.  -92.        0      0      0      2      0        0\n",
+			want: map[string]wirelessStats{
+				"wlan0":  {linkQuality: 54, signal: level(-56)},
+				"wlp3s0": {linkQuality: 70, signal: level(-38), noise: level(-92)},
+			},
+		},
+		{
+			// Drivers reporting quality in percent go above 70.
+			name: "raw quality",
+			in:   header + " wlan0: 0000   94   -45  -256        0      0      0      0      0        0\n",
+			want: map[string]wirelessStats{
+				"wlan0": {linkQuality: 94, signal: level(-45)},
+			},
+		},
+		{
+			name: "unknown levels",
+			in:   header + " wlan0: 0000   0    -256 -256        0      0      0      0      0        0\n",
+			want: map[string]wirelessStats{
+				"wlan0": {},
+			},
+		},
+		{
+			name: "header only",
+			in:   header,
+			want: map[string]wirelessStats{},
+		},
+		{
+			name: "missing colon",
+			in:   header + " wlan0 0000   54.  -56.  -256        0      0      0      0      0        0\n",
+			err:  true,
+		},
+		{
+			name: "too few fields",
+			in:   header + " wlan0: 0000   54.  -56.\n",
+			err:  true,
+		},
+		{
+			name: "invalid value",
+			in:   header + " wlan0: 0000   good  -56.  -256        0      0      0      0      0        0\n",
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseWirelessStats(strings.NewReader(tc.in))
+			if tc.err {
+				if err == nil {
+					t.Fatalf("want an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
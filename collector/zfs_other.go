@@ -0,0 +1,39 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux,!freebsd,!solaris
+// +build !nozfs
+
+package collector
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type zfsCollector struct{}
+
+func init() {
+	// Registered so --collector.zfs is accepted on every platform; there is
+	// nothing to collect here, so it stays disabled by default.
+	registerCollector("zfs", defaultDisabled, NewZfsCollector)
+}
+
+// NewZfsCollector returns a Collector for platforms without ZFS statistics.
+func NewZfsCollector(logger log.Logger) (Collector, error) {
+	return &zfsCollector{}, nil
+}
+
+func (c *zfsCollector) Update(ch chan<- prometheus.Metric) error {
+	return ErrNoData
+}
@@ -17,11 +17,20 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/siebenmann/go-kstat"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 type zfsCollector struct {
@@ -31,29 +40,67 @@ type zfsCollector struct {
 	abdstatsScatterCount         *prometheus.Desc
 	abdstatsScatterDataSize      *prometheus.Desc
 	abdstatsStructSize           *prometheus.Desc
+	arcstatsAllocated            *prometheus.Desc
 	arcstatsAnonSize             *prometheus.Desc
+	arcstatsArcNeedFree          *prometheus.Desc
+	arcstatsArcNoGrow            *prometheus.Desc
 	arcstatsC                    *prometheus.Desc
+	arcstatsCChange              *prometheus.Desc
 	arcstatsCMax                 *prometheus.Desc
 	arcstatsCMin                 *prometheus.Desc
+	arcstatsCompressedSize       *prometheus.Desc
 	arcstatsDataSize             *prometheus.Desc
+	arcstatsDeleted              *prometheus.Desc
 	arcstatsDemandDataHits       *prometheus.Desc
 	arcstatsDemandDataMisses     *prometheus.Desc
 	arcstatsDemandMetadataHits   *prometheus.Desc
 	arcstatsDemandMetadataMisses *prometheus.Desc
+	arcstatsEvictSkip            *prometheus.Desc
+	arcstatsFillRatio            *prometheus.Desc
 	arcstatsHeaderSize           *prometheus.Desc
+	arcstatsHitRatio             *prometheus.Desc
 	arcstatsHits                 *prometheus.Desc
+	arcstatsL2Hits               *prometheus.Desc
+	arcstatsL2Misses             *prometheus.Desc
+	arcstatsL2ReadBytes          *prometheus.Desc
+	arcstatsL2Size               *prometheus.Desc
+	arcstatsL2WriteBytes         *prometheus.Desc
 	arcstatsMisses               *prometheus.Desc
 	arcstatsMFUGhostHits         *prometheus.Desc
 	arcstatsMFUGhostSize         *prometheus.Desc
+	arcstatsMemoryThrottleCount  *prometheus.Desc
 	arcstatsMFUSize              *prometheus.Desc
 	arcstatsMRUGhostHits         *prometheus.Desc
 	arcstatsMRUGhostSize         *prometheus.Desc
 	arcstatsMRUSize              *prometheus.Desc
+	arcstatsMutexMiss            *prometheus.Desc
 	arcstatsOtherSize            *prometheus.Desc
 	arcstatsP                    *prometheus.Desc
+	arcstatsPrefetchDataHits     *prometheus.Desc
+	arcstatsPrefetchDataMisses   *prometheus.Desc
+	arcstatsPrefetchMetaHits     *prometheus.Desc
+	arcstatsPrefetchMetaMisses   *prometheus.Desc
 	arcstatsSize                 *prometheus.Desc
+	arcstatsSnaptime             *prometheus.Desc
+	arcstatsUncompressedSize     *prometheus.Desc
+	kstatChainGeneration         *prometheus.Desc
 	zfetchstatsHits              *prometheus.Desc
+	zfetchstatsMaxStreams        *prometheus.Desc
 	zfetchstatsMisses            *prometheus.Desc
+	vdevActiveOps                *prometheus.Desc
+	vdevPendingOps               *prometheus.Desc
+	zpoolNread                   *prometheus.Desc
+	zpoolNwritten                *prometheus.Desc
+	zpoolReads                   *prometheus.Desc
+	zpoolWrites                  *prometheus.Desc
+	arcstatsExclude              map[string]bool
+	poolIncludePattern           *regexp.Regexp
+	poolExcludePattern           *regexp.Regexp
+	arcstatsInstanceDescs        *zfsCollector
+	trackTargetChanges           bool
+	lastTargetsMtx               sync.Mutex
+	lastTargets                  map[int]uint64
+	openKstat                    func() (kstatToken, error)
 	logger                       log.Logger
 }
 
@@ -61,11 +108,125 @@ const (
 	zfsCollectorSubsystem = "zfs"
 )
 
+var (
+	zfsArcstatsExclude = kingpin.Flag("collector.zfs.arcstats-exclude", "Comma separated list of arcstats kstat names to skip.").Default("").String()
+	zfsPoolInclude     = kingpin.Flag("collector.zfs.pool-include", "Regexp of zpools to include. Defaults to all pools.").Default("").String()
+	zfsPoolExclude     = kingpin.Flag("collector.zfs.pool-exclude", "Regexp of zpools to exclude.").Default("").String()
+	zfsTrackTargets    = kingpin.Flag("collector.zfs.track-target-changes", "Export the change of the ARC target size since the previous scrape.").Default("false").Bool()
+	zfsCacheDuration   = kingpin.Flag("collector.zfs.cache-duration", "How long to replay the ZFS metrics of a scrape before reading the kstats again. 0 reads them on every scrape.").Default("0s").Duration()
+	zfsKstatSource     = kingpin.Flag("collector.zfs.kstat-source", "Source to read ZFS kstats from. \"kstat\" opens the kstat chain of the running kernel, it is the only source so far.").Default("kstat").Enum("kstat")
+)
+
+// kstatToken is the subset of a kstat handle used by the zfs collector.
+type kstatToken interface {
+	All() []*kstat.KStat
+	Lookup(module string, instance int, name string) (namedLookup, error)
+	ChainID() (int64, error)
+	Close() error
+}
+
+// namedLookup is the subset of a kstat used by the zfs collector.
+type namedLookup interface {
+	GetNamed(name string) (*kstat.Named, error)
+	GetIO() (*kstat.IO, error)
+}
+
+// goKstatToken adapts a go-kstat token to kstatToken.
+type goKstatToken struct {
+	*kstat.Token
+}
+
+func (t goKstatToken) Lookup(module string, instance int, name string) (namedLookup, error) {
+	ks, err := t.Token.Lookup(module, instance, name)
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (t goKstatToken) ChainID() (int64, error) {
+	return kstatChainID()
+}
+
+// kstatSources maps the values of --collector.zfs.kstat-source to the
+// function opening a kstat handle on that source. A zone without access to
+// the global zone kstats can add a proxy reader here.
+var kstatSources = map[string]func() (kstatToken, error){
+	"kstat": func() (kstatToken, error) {
+		tok, err := kstat.Open()
+		if err != nil {
+			return nil, err
+		}
+		return goKstatToken{tok}, nil
+	},
+}
+
 func init() {
 	registerCollector("zfs", defaultEnabled, NewZfsCollector)
 }
 
 func NewZfsCollector(logger log.Logger) (Collector, error) {
+	openKstat, ok := kstatSources[*zfsKstatSource]
+	if !ok {
+		return nil, fmt.Errorf("unknown kstat source %q", *zfsKstatSource)
+	}
+
+	c := newZfsDescs(nil)
+	// The label isn't called instance to not clash with the target label
+	// of Prometheus.
+	c.arcstatsInstanceDescs = newZfsDescs([]string{"arcstats_instance"})
+	c.arcstatsExclude = map[string]bool{}
+	c.lastTargets = map[int]uint64{}
+	c.openKstat = openKstat
+	c.trackTargetChanges = *zfsTrackTargets
+	c.logger = logger
+
+	known := c.arcstatsDescs()
+	for k, v := range c.arcstatsL2Descs() {
+		known[k] = v
+	}
+	for _, name := range strings.Split(*zfsArcstatsExclude, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			level.Warn(logger).Log("msg", "Unknown arcstats name in exclude list", "name", name)
+			continue
+		}
+		c.arcstatsExclude[name] = true
+	}
+
+	if *zfsPoolInclude != "" {
+		level.Info(logger).Log("msg", "Parsed flag --collector.zfs.pool-include", "flag", *zfsPoolInclude)
+		pattern, err := regexp.Compile(*zfsPoolInclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --collector.zfs.pool-include: %w", err)
+		}
+		c.poolIncludePattern = pattern
+	}
+	if *zfsPoolExclude != "" {
+		level.Info(logger).Log("msg", "Parsed flag --collector.zfs.pool-exclude", "flag", *zfsPoolExclude)
+		pattern, err := regexp.Compile(*zfsPoolExclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --collector.zfs.pool-exclude: %w", err)
+		}
+		c.poolExcludePattern = pattern
+	}
+
+	if *zfsCacheDuration > 0 {
+		return newCachingCollector(c, *zfsCacheDuration, prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "cache_hit"),
+			"Whether the ZFS metrics were replayed from the cache", nil, nil,
+		)), nil
+	}
+
+	return c, nil
+}
+
+// newZfsDescs returns a zfsCollector holding just the metric descriptors.
+// arcstatsLabels are the variable labels of the arcstats metrics.
+func newZfsDescs(arcstatsLabels []string) *zfsCollector {
 	return &zfsCollector{
 		abdstatsLinearCount: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "abdstats_linear_count_total"),
@@ -91,106 +252,274 @@ func NewZfsCollector(logger log.Logger) (Collector, error) {
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "abdstats_struct_bytes"),
 			"ZFS ARC buffer data struct size", nil, nil,
 		),
+		arcstatsAllocated: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_allocated_total"),
+			"ZFS ARC buffers allocated", arcstatsLabels, nil,
+		),
 		arcstatsAnonSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_anon_bytes"),
-			"ZFS ARC anon size", nil, nil,
+			"ZFS ARC anon size", arcstatsLabels, nil,
+		),
+		arcstatsArcNeedFree: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_arc_need_free_bytes"),
+			"ZFS ARC bytes the kernel asked to be freed", arcstatsLabels, nil,
+		),
+		arcstatsArcNoGrow: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_arc_no_grow"),
+			"ZFS ARC growth disabled under memory pressure", arcstatsLabels, nil,
 		),
 		arcstatsC: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_c_bytes"),
-			"ZFS ARC target size", nil, nil,
+			"ZFS ARC target size", arcstatsLabels, nil,
+		),
+		arcstatsCChange: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_c_change_bytes"),
+			"ZFS ARC target size change since the previous scrape", arcstatsLabels, nil,
 		),
 		arcstatsCMax: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_c_max_bytes"),
-			"ZFS ARC maximum size", nil, nil,
+			"ZFS ARC maximum size", arcstatsLabels, nil,
 		),
 		arcstatsCMin: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_c_min_bytes"),
-			"ZFS ARC minimum size", nil, nil,
+			"ZFS ARC minimum size", arcstatsLabels, nil,
+		),
+		arcstatsCompressedSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_compressed_bytes"),
+			"ZFS ARC compressed size", arcstatsLabels, nil,
 		),
 		arcstatsDataSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_data_bytes"),
-			"ZFS ARC data size", nil, nil,
+			"ZFS ARC data size", arcstatsLabels, nil,
+		),
+		arcstatsDeleted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_deleted_total"),
+			"ZFS ARC buffers deleted", arcstatsLabels, nil,
 		),
 		arcstatsDemandDataHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_demand_data_hits_total"),
-			"ZFS ARC demand data hits", nil, nil,
+			"ZFS ARC demand data hits", arcstatsLabels, nil,
 		),
 		arcstatsDemandDataMisses: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_demand_data_misses_total"),
-			"ZFS ARC demand data misses", nil, nil,
+			"ZFS ARC demand data misses", arcstatsLabels, nil,
 		),
 		arcstatsDemandMetadataHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_demand_metadata_hits_total"),
-			"ZFS ARC demand metadata hits", nil, nil,
+			"ZFS ARC demand metadata hits", arcstatsLabels, nil,
 		),
 		arcstatsDemandMetadataMisses: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_demand_metadata_misses_total"),
-			"ZFS ARC demand metadata misses", nil, nil,
+			"ZFS ARC demand metadata misses", arcstatsLabels, nil,
+		),
+		arcstatsEvictSkip: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_evict_skip_total"),
+			"ZFS ARC evictions skipped", arcstatsLabels, nil,
+		),
+		arcstatsFillRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_fill_ratio"),
+			"ZFS ARC fill ratio, computed as size / c_max", arcstatsLabels, nil,
 		),
 		arcstatsHeaderSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_hdr_bytes"),
-			"ZFS ARC header size", nil, nil,
+			"ZFS ARC header size", arcstatsLabels, nil,
+		),
+		arcstatsHitRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_hit_ratio"),
+			"ZFS ARC hit ratio, computed as hits / (hits + misses)", arcstatsLabels, nil,
 		),
 		arcstatsHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_hits_total"),
-			"ZFS ARC hits", nil, nil,
+			"ZFS ARC hits", arcstatsLabels, nil,
+		),
+		arcstatsL2Hits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_l2_hits_total"),
+			"ZFS L2ARC hits", arcstatsLabels, nil,
+		),
+		arcstatsL2Misses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_l2_misses_total"),
+			"ZFS L2ARC misses", arcstatsLabels, nil,
+		),
+		arcstatsL2ReadBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_l2_read_bytes"),
+			"ZFS L2ARC read bytes", arcstatsLabels, nil,
+		),
+		arcstatsL2Size: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_l2_size_bytes"),
+			"ZFS L2ARC size", arcstatsLabels, nil,
+		),
+		arcstatsL2WriteBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_l2_write_bytes"),
+			"ZFS L2ARC write bytes", arcstatsLabels, nil,
 		),
 		arcstatsMisses: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_misses_total"),
-			"ZFS ARC misses", nil, nil,
+			"ZFS ARC misses", arcstatsLabels, nil,
+		),
+		arcstatsMemoryThrottleCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_memory_throttle_count_total"),
+			"ZFS ARC writes throttled due to memory pressure", arcstatsLabels, nil,
 		),
 		arcstatsMFUGhostHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mfu_ghost_hits_total"),
-			"ZFS ARC MFU ghost hits", nil, nil,
+			"ZFS ARC MFU ghost hits", arcstatsLabels, nil,
 		),
 		arcstatsMFUGhostSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mfu_ghost_size"),
-			"ZFS ARC MFU ghost size", nil, nil,
+			"ZFS ARC MFU ghost size", arcstatsLabels, nil,
 		),
 		arcstatsMFUSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mfu_bytes"),
-			"ZFS ARC MFU size", nil, nil,
+			"ZFS ARC MFU size", arcstatsLabels, nil,
 		),
 		arcstatsMRUGhostHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mru_ghost_hits_total"),
-			"ZFS ARC MRU ghost hits", nil, nil,
+			"ZFS ARC MRU ghost hits", arcstatsLabels, nil,
 		),
 		arcstatsMRUGhostSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mru_ghost_bytes"),
-			"ZFS ARC MRU ghost size", nil, nil,
+			"ZFS ARC MRU ghost size", arcstatsLabels, nil,
 		),
 		arcstatsMRUSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mru_bytes"),
-			"ZFS ARC MRU size", nil, nil,
+			"ZFS ARC MRU size", arcstatsLabels, nil,
+		),
+		arcstatsMutexMiss: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_mutex_miss_total"),
+			"ZFS ARC mutex misses", arcstatsLabels, nil,
 		),
 		arcstatsOtherSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_other_bytes"),
-			"ZFS ARC other size", nil, nil,
+			"ZFS ARC other size", arcstatsLabels, nil,
 		),
 		arcstatsP: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_p_bytes"),
-			"ZFS ARC MRU target size", nil, nil,
+			"ZFS ARC MRU target size", arcstatsLabels, nil,
+		),
+		arcstatsPrefetchDataHits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_prefetch_data_hits_total"),
+			"ZFS ARC prefetch data hits", arcstatsLabels, nil,
+		),
+		arcstatsPrefetchDataMisses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_prefetch_data_misses_total"),
+			"ZFS ARC prefetch data misses", arcstatsLabels, nil,
+		),
+		arcstatsPrefetchMetaHits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_prefetch_metadata_hits_total"),
+			"ZFS ARC prefetch metadata hits", arcstatsLabels, nil,
+		),
+		arcstatsPrefetchMetaMisses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_prefetch_metadata_misses_total"),
+			"ZFS ARC prefetch metadata misses", arcstatsLabels, nil,
 		),
 		arcstatsSize: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_size_bytes"),
-			"ZFS ARC size", nil, nil,
+			"ZFS ARC size", arcstatsLabels, nil,
+		),
+		arcstatsSnaptime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_snaptime_seconds"),
+			"ZFS ARC kstat snapshot time, in seconds since an arbitrary point in the past", arcstatsLabels, nil,
+		),
+		arcstatsUncompressedSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "arcstats_uncompressed_bytes"),
+			"ZFS ARC uncompressed size", arcstatsLabels, nil,
+		),
+		kstatChainGeneration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "kstat_chain_generation"),
+			"ID of the kstat chain, which changes whenever kstats are added or removed", nil, nil,
 		),
 		zfetchstatsHits: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zfetchstats_hits_total"),
 			"ZFS cache fetch hits", nil, nil,
 		),
+		zfetchstatsMaxStreams: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zfetchstats_max_streams"),
+			"ZFS cache fetch max streams", nil, nil,
+		),
 		zfetchstatsMisses: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zfetchstats_misses_total"),
 			"ZFS cache fetch misses", nil, nil,
 		),
-		logger: logger,
-	}, nil
+		vdevActiveOps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "vdev_active_ops"),
+			"ZFS vdev I/O operations issued to the device", []string{"vdev"}, nil,
+		),
+		vdevPendingOps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "vdev_pending_ops"),
+			"ZFS vdev I/O operations waiting in the queue", []string{"vdev"}, nil,
+		),
+		zpoolNread: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zpool_nread_bytes_total"),
+			"ZFS pool bytes read", []string{"zpool"}, nil,
+		),
+		zpoolNwritten: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zpool_nwritten_bytes_total"),
+			"ZFS pool bytes written", []string{"zpool"}, nil,
+		),
+		zpoolReads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zpool_reads_total"),
+			"ZFS pool read operations", []string{"zpool"}, nil,
+		),
+		zpoolWrites: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zfsCollectorSubsystem, "zpool_writes_total"),
+			"ZFS pool write operations", []string{"zpool"}, nil,
+		),
+	}
 }
 
-func (c *zfsCollector) updateZfsAbdStats(ch chan<- prometheus.Metric) error {
+// poolIgnored reports whether metrics for the zpool name are filtered out by
+// --collector.zfs.pool-include or --collector.zfs.pool-exclude.
+func (c *zfsCollector) poolIgnored(name string) bool {
+	if c.poolIncludePattern != nil && !c.poolIncludePattern.MatchString(name) {
+		return true
+	}
+	return c.poolExcludePattern != nil && c.poolExcludePattern.MatchString(name)
+}
+
+// getNamed returns the named statistic k of ks. Named statistics differ
+// between Solaris and illumos releases, so a missing one is logged and
+// reported as not ok instead of failing the whole scrape.
+func (c *zfsCollector) getNamed(ks namedLookup, k string) (*kstat.Named, bool) {
+	v, err := ks.GetNamed(k)
+	if err != nil || v == nil {
+		level.Debug(c.logger).Log("msg", "kstat named statistic not available", "kstat", ks, "name", k, "err", err)
+		return nil, false
+	}
+	return v, true
+}
+
+// openKstatContext opens a kstat handle unless ctx is already done, since
+// opening one snapshots the whole kstat chain.
+func (c *zfsCollector) openKstatContext(ctx context.Context) (kstatToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.openKstat()
+}
+
+func (c *zfsCollector) updateKstatChain(ctx context.Context, ch chan<- prometheus.Metric) error {
+	tok, err := c.openKstatContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tok.Close()
+
+	chainID, err := tok.ChainID()
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "kstat chain ID not available", "err", err)
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.kstatChainGeneration, prometheus.GaugeValue, float64(chainID))
+
+	return nil
+}
+
+func (c *zfsCollector) updateZfsAbdStats(ctx context.Context, ch chan<- prometheus.Metric) error {
 	var metricType prometheus.ValueType
 
-	tok, err := kstat.Open()
+	tok, err := c.openKstatContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -199,6 +528,12 @@ func (c *zfsCollector) updateZfsAbdStats(ch chan<- prometheus.Metric) error {
 
 	ksZFSInfo, err := tok.Lookup("zfs", 0, "abdstats")
 	if err != nil {
+		// abdstats only exist on ZFS versions with ARC buffer data support.
+		level.Debug(c.logger).Log("msg", "abdstats kstat not available", "err", err)
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
@@ -210,9 +545,9 @@ func (c *zfsCollector) updateZfsAbdStats(ch chan<- prometheus.Metric) error {
 		"scatter_data_size":   c.abdstatsScatterDataSize,
 		"struct_size":         c.abdstatsStructSize,
 	} {
-		ksZFSInfoValue, err := ksZFSInfo.GetNamed(k)
-		if err != nil {
-			return err
+		ksZFSInfoValue, ok := c.getNamed(ksZFSInfo, k)
+		if !ok {
+			continue
 		}
 
 		if strings.HasSuffix(k, "_cnt") {
@@ -231,67 +566,189 @@ func (c *zfsCollector) updateZfsAbdStats(ch chan<- prometheus.Metric) error {
 	return nil
 }
 
-func (c *zfsCollector) updateZfsArcStats(ch chan<- prometheus.Metric) error {
-	var metricType prometheus.ValueType
+// arcstatsDescs returns the arcstats named values to export, keyed by kstat
+// name. Values that the running kernel doesn't provide are skipped.
+func (c *zfsCollector) arcstatsDescs() map[string]typedDesc {
+	return map[string]typedDesc{
+		"allocated":                {c.arcstatsAllocated, prometheus.CounterValue},
+		"anon_size":                {c.arcstatsAnonSize, prometheus.GaugeValue},
+		"arc_need_free":            {c.arcstatsArcNeedFree, prometheus.GaugeValue},
+		"arc_no_grow":              {c.arcstatsArcNoGrow, prometheus.GaugeValue},
+		"c":                        {c.arcstatsC, prometheus.GaugeValue},
+		"c_max":                    {c.arcstatsCMax, prometheus.GaugeValue},
+		"c_min":                    {c.arcstatsCMin, prometheus.GaugeValue},
+		"compressed_size":          {c.arcstatsCompressedSize, prometheus.GaugeValue},
+		"data_size":                {c.arcstatsDataSize, prometheus.GaugeValue},
+		"deleted":                  {c.arcstatsDeleted, prometheus.CounterValue},
+		"demand_data_hits":         {c.arcstatsDemandDataHits, prometheus.CounterValue},
+		"demand_data_misses":       {c.arcstatsDemandDataMisses, prometheus.CounterValue},
+		"demand_metadata_hits":     {c.arcstatsDemandMetadataHits, prometheus.CounterValue},
+		"demand_metadata_misses":   {c.arcstatsDemandMetadataMisses, prometheus.CounterValue},
+		"evict_skip":               {c.arcstatsEvictSkip, prometheus.CounterValue},
+		"hdr_size":                 {c.arcstatsHeaderSize, prometheus.GaugeValue},
+		"hits":                     {c.arcstatsHits, prometheus.CounterValue},
+		"memory_throttle_count":    {c.arcstatsMemoryThrottleCount, prometheus.CounterValue},
+		"misses":                   {c.arcstatsMisses, prometheus.CounterValue},
+		"mfu_ghost_hits":           {c.arcstatsMFUGhostHits, prometheus.CounterValue},
+		"mfu_ghost_size":           {c.arcstatsMFUGhostSize, prometheus.GaugeValue},
+		"mfu_size":                 {c.arcstatsMFUSize, prometheus.GaugeValue},
+		"mru_ghost_hits":           {c.arcstatsMRUGhostHits, prometheus.CounterValue},
+		"mru_ghost_size":           {c.arcstatsMRUGhostSize, prometheus.GaugeValue},
+		"mru_size":                 {c.arcstatsMRUSize, prometheus.GaugeValue},
+		"mutex_miss":               {c.arcstatsMutexMiss, prometheus.CounterValue},
+		"other_size":               {c.arcstatsOtherSize, prometheus.GaugeValue},
+		"p":                        {c.arcstatsP, prometheus.GaugeValue},
+		"prefetch_data_hits":       {c.arcstatsPrefetchDataHits, prometheus.CounterValue},
+		"prefetch_data_misses":     {c.arcstatsPrefetchDataMisses, prometheus.CounterValue},
+		"prefetch_metadata_hits":   {c.arcstatsPrefetchMetaHits, prometheus.CounterValue},
+		"prefetch_metadata_misses": {c.arcstatsPrefetchMetaMisses, prometheus.CounterValue},
+		"size":                     {c.arcstatsSize, prometheus.GaugeValue},
+		"uncompressed_size":        {c.arcstatsUncompressedSize, prometheus.GaugeValue},
+	}
+}
 
-	tok, err := kstat.Open()
+// arcstatsL2Descs returns the L2ARC arcstats named values, keyed by kstat name.
+func (c *zfsCollector) arcstatsL2Descs() map[string]typedDesc {
+	return map[string]typedDesc{
+		"l2_hits":        {c.arcstatsL2Hits, prometheus.CounterValue},
+		"l2_misses":      {c.arcstatsL2Misses, prometheus.CounterValue},
+		"l2_read_bytes":  {c.arcstatsL2ReadBytes, prometheus.GaugeValue},
+		"l2_size":        {c.arcstatsL2Size, prometheus.GaugeValue},
+		"l2_write_bytes": {c.arcstatsL2WriteBytes, prometheus.GaugeValue},
+	}
+}
+
+func (c *zfsCollector) updateZfsArcStats(ctx context.Context, ch chan<- prometheus.Metric) error {
+	tok, err := c.openKstatContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	defer tok.Close()
 
-	ksZFSInfo, err := tok.Lookup("zfs", 0, "arcstats")
-	if err != nil {
-		return err
+	// There is normally one ARC, but some builds keep several, numbered from
+	// instance 0 upwards.
+	var instances []namedLookup
+	for instance := 0; ; instance++ {
+		ksZFSInfo, err := tok.Lookup("zfs", instance, "arcstats")
+		if err != nil {
+			if instance > 0 {
+				break
+			}
+			if errors.Is(err, syscall.ENOENT) {
+				// ZFS isn't loaded, so there is nothing to report.
+				level.Debug(c.logger).Log("msg", "arcstats kstat not available", "err", err)
+				return ErrNoData
+			}
+			return err
+		}
+		instances = append(instances, ksZFSInfo)
 	}
 
-	for k, v := range map[string]*prometheus.Desc{
-		"anon_size":              c.arcstatsAnonSize,
-		"c":                      c.arcstatsC,
-		"c_max":                  c.arcstatsCMax,
-		"c_min":                  c.arcstatsCMin,
-		"data_size":              c.arcstatsDataSize,
-		"demand_data_hits":       c.arcstatsDemandDataHits,
-		"demand_data_misses":     c.arcstatsDemandDataMisses,
-		"demand_metadata_hits":   c.arcstatsDemandMetadataHits,
-		"demand_metadata_misses": c.arcstatsDemandMetadataMisses,
-		"hdr_size":               c.arcstatsHeaderSize,
-		"hits":                   c.arcstatsHits,
-		"misses":                 c.arcstatsMisses,
-		"mfu_ghost_hits":         c.arcstatsMFUGhostHits,
-		"mfu_ghost_size":         c.arcstatsMFUGhostSize,
-		"mfu_size":               c.arcstatsMFUSize,
-		"mru_ghost_hits":         c.arcstatsMRUGhostHits,
-		"mru_ghost_size":         c.arcstatsMRUGhostSize,
-		"mru_size":               c.arcstatsMRUSize,
-		"other_size":             c.arcstatsOtherSize,
-		"p":                      c.arcstatsP,
-		"size":                   c.arcstatsSize,
-	} {
-		ksZFSInfoValue, err := ksZFSInfo.GetNamed(k)
-		if err != nil {
+	for instance, ksZFSInfo := range instances {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		if strings.HasSuffix(k, "_hits") || strings.HasSuffix(k, "_misses") {
-			metricType = prometheus.CounterValue
-		} else {
-			metricType = prometheus.GaugeValue
+		// A single ARC keeps the unlabeled metrics.
+		d, labels := c, []string(nil)
+		if len(instances) > 1 {
+			d, labels = c.arcstatsInstanceDescs, []string{strconv.Itoa(instance)}
+		}
+		c.updateArcstatsInstance(ch, ksZFSInfo, instance, d, labels)
+	}
+
+	return nil
+}
+
+// updateArcstatsInstance exports one arcstats kstat, using the descriptors of
+// d with the given label values.
+func (c *zfsCollector) updateArcstatsInstance(ch chan<- prometheus.Metric, ksZFSInfo namedLookup, instance int, d *zfsCollector, labels []string) {
+	for k, v := range d.arcstatsDescs() {
+		if c.arcstatsExclude[k] {
+			continue
+		}
+
+		ksZFSInfoValue, ok := c.getNamed(ksZFSInfo, k)
+		if !ok {
+			continue
 		}
 
+		ch <- v.mustNewConstMetric(float64(ksZFSInfoValue.UintVal), labels...)
+	}
+
+	if c.trackTargetChanges {
+		if target, ok := c.getNamed(ksZFSInfo, "c"); ok {
+			if change, ok := c.targetChange(instance, target.UintVal); ok {
+				ch <- prometheus.MustNewConstMetric(d.arcstatsCChange, prometheus.GaugeValue, change, labels...)
+			}
+		}
+	}
+
+	size, sizeOk := c.getNamed(ksZFSInfo, "size")
+	cMax, cMaxOk := c.getNamed(ksZFSInfo, "c_max")
+	if sizeOk && cMaxOk && cMax.UintVal > 0 {
 		ch <- prometheus.MustNewConstMetric(
-			v,
-			metricType,
-			float64(ksZFSInfoValue.UintVal),
+			d.arcstatsFillRatio,
+			prometheus.GaugeValue,
+			float64(size.UintVal)/float64(cMax.UintVal),
+			labels...,
 		)
 	}
 
-	return nil
+	hits, hitsOk := c.getNamed(ksZFSInfo, "hits")
+	misses, missesOk := c.getNamed(ksZFSInfo, "misses")
+	if hitsOk && missesOk {
+		if total := hits.UintVal + misses.UintVal; total > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				d.arcstatsHitRatio,
+				prometheus.GaugeValue,
+				float64(hits.UintVal)/float64(total),
+				labels...,
+			)
+		}
+	}
+
+	// Snaptime is a gethrtime(3C) timestamp in nanoseconds of when the named
+	// values were read; it is left at zero if the kstat data was never read.
+	if hitsOk && hits.Snaptime != 0 {
+		ch <- prometheus.MustNewConstMetric(
+			d.arcstatsSnaptime,
+			prometheus.GaugeValue,
+			float64(hits.Snaptime)/1e9,
+			labels...,
+		)
+	}
+
+	// L2ARC statistics are only present if a cache device is configured.
+	for k, v := range d.arcstatsL2Descs() {
+		if c.arcstatsExclude[k] {
+			continue
+		}
+
+		ksZFSInfoValue, ok := c.getNamed(ksZFSInfo, k)
+		if !ok {
+			continue
+		}
+
+		ch <- v.mustNewConstMetric(float64(ksZFSInfoValue.UintVal), labels...)
+	}
+}
+
+// targetChange records the ARC target size of an arcstats instance and returns
+// its change since the previous call. There is no change to report on the
+// first call.
+func (c *zfsCollector) targetChange(instance int, target uint64) (float64, bool) {
+	c.lastTargetsMtx.Lock()
+	defer c.lastTargetsMtx.Unlock()
+
+	last, ok := c.lastTargets[instance]
+	c.lastTargets[instance] = target
+	return float64(target) - float64(last), ok
 }
 
-func (c *zfsCollector) updateZfsFetchStats(ch chan<- prometheus.Metric) error {
-	tok, err := kstat.Open()
+func (c *zfsCollector) updateZfsFetchStats(ctx context.Context, ch chan<- prometheus.Metric) error {
+	tok, err := c.openKstatContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -299,35 +756,149 @@ func (c *zfsCollector) updateZfsFetchStats(ch chan<- prometheus.Metric) error {
 	defer tok.Close()
 
 	ksZFSInfo, err := tok.Lookup("zfs", 0, "zfetchstats")
+	if err != nil {
+		level.Debug(c.logger).Log("msg", "zfetchstats kstat not available", "err", err)
+		return nil
+	}
 
-	for k, v := range map[string]*prometheus.Desc{
-		"hits":   c.zfetchstatsHits,
-		"misses": c.zfetchstatsMisses,
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for k, v := range map[string]typedDesc{
+		"hits":        {c.zfetchstatsHits, prometheus.CounterValue},
+		"max_streams": {c.zfetchstatsMaxStreams, prometheus.GaugeValue},
+		"misses":      {c.zfetchstatsMisses, prometheus.CounterValue},
 	} {
-		ksZFSInfoValue, err := ksZFSInfo.GetNamed(k)
+		ksZFSInfoValue, ok := c.getNamed(ksZFSInfo, k)
+		if !ok {
+			continue
+		}
+
+		ch <- v.mustNewConstMetric(float64(ksZFSInfoValue.UintVal))
+	}
+
+	return nil
+}
+
+func (c *zfsCollector) updateVdevStats(ctx context.Context, ch chan<- prometheus.Metric) error {
+	tok, err := c.openKstatContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tok.Close()
+
+	// vdev kstats are only present on ZFS versions with per-vdev queue
+	// statistics.
+	for _, ks := range tok.All() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if ks.Module != "zfs" || ks.Name != "vdev" || ks.Type != kstat.NamedStat {
+			continue
+		}
+
+		ksVdev, err := tok.Lookup(ks.Module, ks.Instance, ks.Name)
 		if err != nil {
+			// The vdev may have been removed since the chain was read.
+			level.Debug(c.logger).Log("msg", "Failed to look up vdev kstat", "instance", ks.Instance, "err", err)
+			continue
+		}
+
+		// Instance numbers are reassigned as vdevs come and go, so the vdev
+		// is identified by its name.
+		name, ok := c.getNamed(ksVdev, "name")
+		if !ok || name.StringVal == "" {
+			continue
+		}
+
+		for k, v := range map[string]*prometheus.Desc{
+			"active_ops":  c.vdevActiveOps,
+			"pending_ops": c.vdevPendingOps,
+		} {
+			ksVdevValue, ok := c.getNamed(ksVdev, k)
+			if !ok {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(v, prometheus.GaugeValue, float64(ksVdevValue.UintVal), name.StringVal)
+		}
+	}
+
+	return nil
+}
+
+func (c *zfsCollector) updateZpoolStats(ctx context.Context, ch chan<- prometheus.Metric) error {
+	tok, err := c.openKstatContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tok.Close()
+
+	// Every imported pool has an I/O kstat named after it in the zfs module.
+	for _, ks := range tok.All() {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		ch <- prometheus.MustNewConstMetric(
-			v,
-			prometheus.CounterValue,
-			float64(ksZFSInfoValue.UintVal),
-		)
+		if ks.Module != "zfs" || ks.Type != kstat.IoStat {
+			continue
+		}
+
+		if c.poolIgnored(ks.Name) {
+			level.Debug(c.logger).Log("msg", "Ignoring zpool", "zpool", ks.Name)
+			continue
+		}
+
+		ksZpool, err := tok.Lookup(ks.Module, ks.Instance, ks.Name)
+		if err != nil {
+			// The pool may have been exported since the chain was read.
+			level.Debug(c.logger).Log("msg", "Failed to look up zpool kstat", "zpool", ks.Name, "err", err)
+			continue
+		}
+
+		ksZpoolIO, err := ksZpool.GetIO()
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "Failed to read zpool I/O kstat", "zpool", ks.Name, "err", err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.zpoolNread, prometheus.CounterValue, float64(ksZpoolIO.Nread), ks.Name)
+		ch <- prometheus.MustNewConstMetric(c.zpoolNwritten, prometheus.CounterValue, float64(ksZpoolIO.Nwritten), ks.Name)
+		ch <- prometheus.MustNewConstMetric(c.zpoolReads, prometheus.CounterValue, float64(ksZpoolIO.Reads), ks.Name)
+		ch <- prometheus.MustNewConstMetric(c.zpoolWrites, prometheus.CounterValue, float64(ksZpoolIO.Writes), ks.Name)
 	}
 
 	return nil
 }
 
 func (c *zfsCollector) Update(ch chan<- prometheus.Metric) error {
-	if err := c.updateZfsAbdStats(ch); err != nil {
-		return err
+	return c.UpdateContext(context.Background(), ch)
+}
+
+// UpdateContext implements ContextCollector. A canceled ctx aborts the update
+// before the next kstat lookup is turned into metrics.
+func (c *zfsCollector) UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if err := c.updateZfsAbdStats(ctx, ch); err != nil {
+		return fmt.Errorf("updateZfsAbdStats: %w", err)
 	}
-	if err := c.updateZfsArcStats(ch); err != nil {
-		return err
+	if err := c.updateZfsArcStats(ctx, ch); err != nil {
+		return fmt.Errorf("updateZfsArcStats: %w", err)
 	}
-	if err := c.updateZfsFetchStats(ch); err != nil {
-		return err
+	if err := c.updateKstatChain(ctx, ch); err != nil {
+		return fmt.Errorf("updateKstatChain: %w", err)
+	}
+	if err := c.updateZfsFetchStats(ctx, ch); err != nil {
+		return fmt.Errorf("updateZfsFetchStats: %w", err)
+	}
+	if err := c.updateVdevStats(ctx, ch); err != nil {
+		return fmt.Errorf("updateVdevStats: %w", err)
+	}
+	if err := c.updateZpoolStats(ctx, ch); err != nil {
+		return fmt.Errorf("updateZpoolStats: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,517 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/siebenmann/go-kstat"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// fakeKstatToken serves named values keyed by "module:instance:name".
+type fakeKstatToken map[string]fakeKstat
+
+func (t fakeKstatToken) All() []*kstat.KStat {
+	var all []*kstat.KStat
+	for key := range t {
+		f := strings.SplitN(key, ":", 3)
+		instance, err := strconv.Atoi(f[1])
+		if err != nil {
+			panic(err)
+		}
+		ksType := kstat.NamedStat
+		if _, ok := t[key][fakeIOKey]; ok {
+			ksType = kstat.IoStat
+		}
+		all = append(all, &kstat.KStat{Module: f[0], Instance: instance, Name: f[2], Type: ksType})
+	}
+	return all
+}
+
+func (t fakeKstatToken) Lookup(module string, instance int, name string) (namedLookup, error) {
+	ks, ok := t[fmt.Sprintf("%s:%d:%s", module, instance, name)]
+	if !ok {
+		// kstat_lookup(3KSTAT) sets ENOENT for unknown kstats.
+		return nil, syscall.ENOENT
+	}
+	return ks, nil
+}
+
+func (t fakeKstatToken) ChainID() (int64, error) {
+	return 0, errors.New("no kstat chain")
+}
+
+func (t fakeKstatToken) Close() error {
+	return nil
+}
+
+// chainKstatToken is a fakeKstatToken holding the kstat chain with ID id.
+type chainKstatToken struct {
+	fakeKstatToken
+	id int64
+}
+
+func (t chainKstatToken) ChainID() (int64, error) {
+	return t.id, nil
+}
+
+// fakeKstat holds named values, either uint64 or string. An I/O kstat holds
+// its *kstat.IO under fakeIOKey instead.
+type fakeKstat map[string]interface{}
+
+// fakeIOKey can't clash with a named value, those are never empty.
+const fakeIOKey = ""
+
+func (k fakeKstat) GetNamed(name string) (*kstat.Named, error) {
+	v, ok := k[name]
+	if !ok {
+		return nil, errors.New("no such named value")
+	}
+	named := &kstat.Named{Name: name, Snaptime: 1500000000}
+	switch v := v.(type) {
+	case uint64:
+		named.Type, named.UintVal = kstat.Uint64, v
+	case string:
+		named.Type, named.StringVal = kstat.String, v
+	}
+	return named, nil
+}
+
+func (k fakeKstat) GetIO() (*kstat.IO, error) {
+	io, ok := k[fakeIOKey].(*kstat.IO)
+	if !ok {
+		return nil, errors.New("not an I/O kstat")
+	}
+	return io, nil
+}
+
+func TestZfsCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		kstats fakeKstatToken
+		want   string
+	}{
+		{
+			name: "arcstats",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {"arc_no_grow": uint64(1), "c_max": uint64(16384), "demand_data_hits": uint64(75), "demand_data_misses": uint64(25), "size": uint64(4096)},
+			},
+			want: `# HELP node_zfs_arcstats_arc_no_grow ZFS ARC growth disabled under memory pressure
+# TYPE node_zfs_arcstats_arc_no_grow gauge
+node_zfs_arcstats_arc_no_grow 1
+# HELP node_zfs_arcstats_c_max_bytes ZFS ARC maximum size
+# TYPE node_zfs_arcstats_c_max_bytes gauge
+node_zfs_arcstats_c_max_bytes 16384
+# HELP node_zfs_arcstats_demand_data_hits_total ZFS ARC demand data hits
+# TYPE node_zfs_arcstats_demand_data_hits_total counter
+node_zfs_arcstats_demand_data_hits_total 75
+# HELP node_zfs_arcstats_demand_data_misses_total ZFS ARC demand data misses
+# TYPE node_zfs_arcstats_demand_data_misses_total counter
+node_zfs_arcstats_demand_data_misses_total 25
+# HELP node_zfs_arcstats_fill_ratio ZFS ARC fill ratio, computed as size / c_max
+# TYPE node_zfs_arcstats_fill_ratio gauge
+node_zfs_arcstats_fill_ratio 0.25
+# HELP node_zfs_arcstats_size_bytes ZFS ARC size
+# TYPE node_zfs_arcstats_size_bytes gauge
+node_zfs_arcstats_size_bytes 4096
+`,
+		},
+		{
+			name: "arcstats counters",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {"deleted": uint64(11), "hits": uint64(75), "misses": uint64(25), "mutex_miss": uint64(7), "evict_skip": uint64(9), "memory_throttle_count": uint64(1), "prefetch_metadata_misses": uint64(3)},
+			},
+			want: `# HELP node_zfs_arcstats_deleted_total ZFS ARC buffers deleted
+# TYPE node_zfs_arcstats_deleted_total counter
+node_zfs_arcstats_deleted_total 11
+# HELP node_zfs_arcstats_evict_skip_total ZFS ARC evictions skipped
+# TYPE node_zfs_arcstats_evict_skip_total counter
+node_zfs_arcstats_evict_skip_total 9
+# HELP node_zfs_arcstats_hit_ratio ZFS ARC hit ratio, computed as hits / (hits + misses)
+# TYPE node_zfs_arcstats_hit_ratio gauge
+node_zfs_arcstats_hit_ratio 0.75
+# HELP node_zfs_arcstats_hits_total ZFS ARC hits
+# TYPE node_zfs_arcstats_hits_total counter
+node_zfs_arcstats_hits_total 75
+# HELP node_zfs_arcstats_memory_throttle_count_total ZFS ARC writes throttled due to memory pressure
+# TYPE node_zfs_arcstats_memory_throttle_count_total counter
+node_zfs_arcstats_memory_throttle_count_total 1
+# HELP node_zfs_arcstats_misses_total ZFS ARC misses
+# TYPE node_zfs_arcstats_misses_total counter
+node_zfs_arcstats_misses_total 25
+# HELP node_zfs_arcstats_mutex_miss_total ZFS ARC mutex misses
+# TYPE node_zfs_arcstats_mutex_miss_total counter
+node_zfs_arcstats_mutex_miss_total 7
+# HELP node_zfs_arcstats_prefetch_metadata_misses_total ZFS ARC prefetch metadata misses
+# TYPE node_zfs_arcstats_prefetch_metadata_misses_total counter
+node_zfs_arcstats_prefetch_metadata_misses_total 3
+# HELP node_zfs_arcstats_snaptime_seconds ZFS ARC kstat snapshot time, in seconds since an arbitrary point in the past
+# TYPE node_zfs_arcstats_snaptime_seconds gauge
+node_zfs_arcstats_snaptime_seconds 1.5
+`,
+		},
+		{
+			name: "arcstats with L2ARC and zfetchstats",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats":    {"l2_hits": uint64(3), "l2_size": uint64(1024)},
+				"zfs:0:zfetchstats": {"hits": uint64(10), "misses": uint64(2), "max_streams": uint64(4)},
+			},
+			want: `# HELP node_zfs_arcstats_l2_hits_total ZFS L2ARC hits
+# TYPE node_zfs_arcstats_l2_hits_total counter
+node_zfs_arcstats_l2_hits_total 3
+# HELP node_zfs_arcstats_l2_size_bytes ZFS L2ARC size
+# TYPE node_zfs_arcstats_l2_size_bytes gauge
+node_zfs_arcstats_l2_size_bytes 1024
+# HELP node_zfs_zfetchstats_hits_total ZFS cache fetch hits
+# TYPE node_zfs_zfetchstats_hits_total counter
+node_zfs_zfetchstats_hits_total 10
+# HELP node_zfs_zfetchstats_max_streams ZFS cache fetch max streams
+# TYPE node_zfs_zfetchstats_max_streams gauge
+node_zfs_zfetchstats_max_streams 4
+# HELP node_zfs_zfetchstats_misses_total ZFS cache fetch misses
+# TYPE node_zfs_zfetchstats_misses_total counter
+node_zfs_zfetchstats_misses_total 2
+`,
+		},
+		{
+			name: "multiple arcstats instances",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {"size": uint64(4096)},
+				"zfs:1:arcstats": {"size": uint64(2048), "l2_size": uint64(512)},
+			},
+			want: `# HELP node_zfs_arcstats_l2_size_bytes ZFS L2ARC size
+# TYPE node_zfs_arcstats_l2_size_bytes gauge
+node_zfs_arcstats_l2_size_bytes{arcstats_instance="1"} 512
+# HELP node_zfs_arcstats_size_bytes ZFS ARC size
+# TYPE node_zfs_arcstats_size_bytes gauge
+node_zfs_arcstats_size_bytes{arcstats_instance="0"} 4096
+node_zfs_arcstats_size_bytes{arcstats_instance="1"} 2048
+`,
+		},
+		{
+			name: "vdevs",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {},
+				"zfs:3:vdev":     {"name": "c1t0d0", "pending_ops": uint64(5), "active_ops": uint64(2)},
+				"zfs:7:vdev":     {"name": "c1t1d0", "pending_ops": uint64(0)},
+			},
+			want: `# HELP node_zfs_vdev_active_ops ZFS vdev I/O operations issued to the device
+# TYPE node_zfs_vdev_active_ops gauge
+node_zfs_vdev_active_ops{vdev="c1t0d0"} 2
+# HELP node_zfs_vdev_pending_ops ZFS vdev I/O operations waiting in the queue
+# TYPE node_zfs_vdev_pending_ops gauge
+node_zfs_vdev_pending_ops{vdev="c1t0d0"} 5
+node_zfs_vdev_pending_ops{vdev="c1t1d0"} 0
+`,
+		},
+	}
+
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewZfsCollector(log.NewNopLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			kstats := test.kstats
+			c.(*zfsCollector).openKstat = func() (kstatToken, error) { return kstats, nil }
+
+			if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(test.want)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestZfsNoArcstats(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZfsCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.(*zfsCollector).openKstat = func() (kstatToken, error) { return fakeKstatToken{}, nil }
+
+	ch := make(chan prometheus.Metric, 10)
+	if err := c.Update(ch); !IsNoDataError(err) {
+		t.Fatalf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestZfsKstatSource(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.zfs.kstat-source", "proxy"}); err == nil {
+		t.Error("want an error for an unknown kstat source")
+	}
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.zfs.kstat-source", "kstat"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	if _, err := NewZfsCollector(log.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZfsKstatChain(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZfsCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kstats := chainKstatToken{
+		fakeKstatToken: fakeKstatToken{"zfs:0:arcstats": {"size": uint64(4096)}},
+		id:             42,
+	}
+	c.(*zfsCollector).openKstat = func() (kstatToken, error) { return kstats, nil }
+
+	want := `# HELP node_zfs_kstat_chain_generation ID of the kstat chain, which changes whenever kstats are added or removed
+# TYPE node_zfs_kstat_chain_generation gauge
+node_zfs_kstat_chain_generation 42
+`
+	if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(want), "node_zfs_kstat_chain_generation"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestZfsCanceledContext(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZfsCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened := false
+	c.(*zfsCollector).openKstat = func() (kstatToken, error) {
+		opened = true
+		return fakeKstatToken{}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch := make(chan prometheus.Metric, 10)
+	if err := c.(ContextCollector).UpdateContext(ctx, ch); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if opened {
+		t.Error("kstat was opened for a canceled scrape")
+	}
+}
+
+func TestZfsTargetChanges(t *testing.T) {
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.zfs.track-target-changes"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewZfsCollector(log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kstats := fakeKstatToken{"zfs:0:arcstats": {"c": uint64(4096)}}
+	c.(*zfsCollector).openKstat = func() (kstatToken, error) { return kstats, nil }
+
+	for _, scrape := range []struct {
+		target uint64
+		want   string
+	}{
+		{4096, ""},
+		{1024, `# HELP node_zfs_arcstats_c_change_bytes ZFS ARC target size change since the previous scrape
+# TYPE node_zfs_arcstats_c_change_bytes gauge
+node_zfs_arcstats_c_change_bytes -3072
+`},
+		{1536, `# HELP node_zfs_arcstats_c_change_bytes ZFS ARC target size change since the previous scrape
+# TYPE node_zfs_arcstats_c_change_bytes gauge
+node_zfs_arcstats_c_change_bytes 512
+`},
+	} {
+		kstats["zfs:0:arcstats"]["c"] = scrape.target
+		if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(scrape.want), "node_zfs_arcstats_c_change_bytes"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestZfsZpoolStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		kstats fakeKstatToken
+		want   string
+	}{
+		{
+			name: "pools",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {},
+				"zfs:0:rpool":    {fakeIOKey: &kstat.IO{Nread: 4096, Nwritten: 8192, Reads: 3, Writes: 5}},
+				"zfs:0:tank":     {fakeIOKey: &kstat.IO{Nread: 1 << 30, Nwritten: 1 << 31, Reads: 1000, Writes: 2000}},
+			},
+			want: `# HELP node_zfs_zpool_nread_bytes_total ZFS pool bytes read
+# TYPE node_zfs_zpool_nread_bytes_total counter
+node_zfs_zpool_nread_bytes_total{zpool="rpool"} 4096
+node_zfs_zpool_nread_bytes_total{zpool="tank"} 1.073741824e+09
+# HELP node_zfs_zpool_nwritten_bytes_total ZFS pool bytes written
+# TYPE node_zfs_zpool_nwritten_bytes_total counter
+node_zfs_zpool_nwritten_bytes_total{zpool="rpool"} 8192
+node_zfs_zpool_nwritten_bytes_total{zpool="tank"} 2.147483648e+09
+# HELP node_zfs_zpool_reads_total ZFS pool read operations
+# TYPE node_zfs_zpool_reads_total counter
+node_zfs_zpool_reads_total{zpool="rpool"} 3
+node_zfs_zpool_reads_total{zpool="tank"} 1000
+# HELP node_zfs_zpool_writes_total ZFS pool write operations
+# TYPE node_zfs_zpool_writes_total counter
+node_zfs_zpool_writes_total{zpool="rpool"} 5
+node_zfs_zpool_writes_total{zpool="tank"} 2000
+`,
+		},
+		{
+			name: "I/O kstats of other modules",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {},
+				"zfs:0:rpool":    {fakeIOKey: &kstat.IO{Nread: 4096, Nwritten: 8192, Reads: 3, Writes: 5}},
+				"sd:0:sd0":       {fakeIOKey: &kstat.IO{Nread: 1, Nwritten: 2, Reads: 3, Writes: 4}},
+			},
+			want: `# HELP node_zfs_zpool_nread_bytes_total ZFS pool bytes read
+# TYPE node_zfs_zpool_nread_bytes_total counter
+node_zfs_zpool_nread_bytes_total{zpool="rpool"} 4096
+# HELP node_zfs_zpool_nwritten_bytes_total ZFS pool bytes written
+# TYPE node_zfs_zpool_nwritten_bytes_total counter
+node_zfs_zpool_nwritten_bytes_total{zpool="rpool"} 8192
+# HELP node_zfs_zpool_reads_total ZFS pool read operations
+# TYPE node_zfs_zpool_reads_total counter
+node_zfs_zpool_reads_total{zpool="rpool"} 3
+# HELP node_zfs_zpool_writes_total ZFS pool write operations
+# TYPE node_zfs_zpool_writes_total counter
+node_zfs_zpool_writes_total{zpool="rpool"} 5
+`,
+		},
+		{
+			name: "excluded pool",
+			args: []string{"--collector.zfs.pool-exclude=^rpool$"},
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {},
+				"zfs:0:rpool":    {fakeIOKey: &kstat.IO{Nread: 4096, Nwritten: 8192, Reads: 3, Writes: 5}},
+				"zfs:0:tank":     {fakeIOKey: &kstat.IO{Nread: 1024, Nwritten: 2048, Reads: 1, Writes: 2}},
+			},
+			want: `# HELP node_zfs_zpool_nread_bytes_total ZFS pool bytes read
+# TYPE node_zfs_zpool_nread_bytes_total counter
+node_zfs_zpool_nread_bytes_total{zpool="tank"} 1024
+# HELP node_zfs_zpool_nwritten_bytes_total ZFS pool bytes written
+# TYPE node_zfs_zpool_nwritten_bytes_total counter
+node_zfs_zpool_nwritten_bytes_total{zpool="tank"} 2048
+# HELP node_zfs_zpool_reads_total ZFS pool read operations
+# TYPE node_zfs_zpool_reads_total counter
+node_zfs_zpool_reads_total{zpool="tank"} 1
+# HELP node_zfs_zpool_writes_total ZFS pool write operations
+# TYPE node_zfs_zpool_writes_total counter
+node_zfs_zpool_writes_total{zpool="tank"} 2
+`,
+		},
+		{
+			name: "unreadable pool",
+			kstats: fakeKstatToken{
+				"zfs:0:arcstats": {},
+				"zfs:0:rpool":    {fakeIOKey: nil},
+			},
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := kingpin.CommandLine.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+			defer kingpin.CommandLine.Parse([]string{})
+
+			c, err := NewZfsCollector(log.NewNopLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			kstats := test.kstats
+			c.(*zfsCollector).openKstat = func() (kstatToken, error) { return kstats, nil }
+
+			if err := testutil.CollectAndCompare(uncheckedCollector{c}, strings.NewReader(test.want),
+				"node_zfs_zpool_nread_bytes_total", "node_zfs_zpool_nwritten_bytes_total",
+				"node_zfs_zpool_reads_total", "node_zfs_zpool_writes_total"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestZfsPoolFilter(t *testing.T) {
+	tests := []struct {
+		args    []string
+		ignored []string
+		kept    []string
+	}{
+		{
+			kept: []string{"rpool", "tank"},
+		},
+		{
+			args:    []string{"--collector.zfs.pool-include=^tank"},
+			ignored: []string{"rpool"},
+			kept:    []string{"tank", "tank2"},
+		},
+		{
+			args:    []string{"--collector.zfs.pool-include=^tank", "--collector.zfs.pool-exclude=2$"},
+			ignored: []string{"rpool", "tank2"},
+			kept:    []string{"tank"},
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := kingpin.CommandLine.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewZfsCollector(log.NewNopLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pool := range test.ignored {
+			if !c.(*zfsCollector).poolIgnored(pool) {
+				t.Errorf("%v: expected zpool %q to be ignored", test.args, pool)
+			}
+		}
+		for _, pool := range test.kept {
+			if c.(*zfsCollector).poolIgnored(pool) {
+				t.Errorf("%v: expected zpool %q to be kept", test.args, pool)
+			}
+		}
+	}
+
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.zfs.pool-exclude=("}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewZfsCollector(log.NewNopLogger()); err == nil {
+		t.Error("expected an error for an invalid pool-exclude regexp")
+	}
+}
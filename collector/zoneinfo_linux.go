@@ -0,0 +1,133 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozoneinfo
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const zoneinfoSubsystem = "zoneinfo"
+
+// zoneinfoFields maps the fields of a zone in /proc/zoneinfo to the exposed
+// metrics and their help.
+var zoneinfoFields = map[string][2]string{
+	"free":    {"free_pages", "Number of free pages in the zone."},
+	"min":     {"min_pages", "Watermark below which allocations of the zone are throttled."},
+	"low":     {"low_pages", "Watermark below which kswapd starts reclaiming pages of the zone."},
+	"high":    {"high_pages", "Watermark at which kswapd stops reclaiming pages of the zone."},
+	"spanned": {"spanned_pages", "Number of pages spanned by the zone, including holes."},
+	"present": {"present_pages", "Number of physical pages in the zone."},
+	"managed": {"managed_pages", "Number of pages of the zone managed by the buddy allocator."},
+}
+
+// zoneinfoStat is a field of a zone in /proc/zoneinfo.
+type zoneinfoStat struct {
+	node, zone, field string
+	value             float64
+}
+
+type zoneinfoCollector struct {
+	descs  map[string]*prometheus.Desc
+	logger log.Logger
+}
+
+func init() {
+	registerCollector(zoneinfoSubsystem, defaultDisabled, NewZoneinfoCollector)
+}
+
+// NewZoneinfoCollector returns a new Collector exposing the free pages and
+// watermarks of the memory zones from /proc/zoneinfo.
+func NewZoneinfoCollector(logger log.Logger) (Collector, error) {
+	descs := make(map[string]*prometheus.Desc, len(zoneinfoFields))
+	for field, metric := range zoneinfoFields {
+		descs[field] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, zoneinfoSubsystem, metric[0]),
+			metric[1],
+			[]string{"node", "zone"}, nil,
+		)
+	}
+	return &zoneinfoCollector{
+		descs:  descs,
+		logger: logger,
+	}, nil
+}
+
+func (c *zoneinfoCollector) Update(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("zoneinfo"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stats, err := parseZoneinfo(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse zoneinfo: %w", err)
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.descs[s.field], prometheus.GaugeValue, s.value, s.node, s.zone)
+	}
+	return nil
+}
+
+// parseZoneinfo reads the zone fields of zoneinfoFields line by line. Each
+// zone starts with a "Node 0, zone DMA" line, the first zone of a node is
+// followed by per-node stats before the zone fields start with "pages free".
+func parseZoneinfo(r io.Reader) ([]zoneinfoStat, error) {
+	var (
+		stats      []zoneinfoStat
+		node, zone string
+		inZone     bool
+		scanner    = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Node" {
+			if len(fields) != 4 || fields[2] != "zone" {
+				return nil, fmt.Errorf("invalid line in zoneinfo: %q", scanner.Text())
+			}
+			node, zone = strings.TrimSuffix(fields[1], ","), fields[3]
+			inZone = false
+			continue
+		}
+		if fields[0] == "pages" && len(fields) == 3 && fields[1] == "free" {
+			fields = fields[1:]
+			inZone = true
+		}
+		if !inZone || len(fields) != 2 {
+			continue
+		}
+		if _, ok := zoneinfoFields[fields[0]]; !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q in zoneinfo: %w", fields[1], err)
+		}
+		stats = append(stats, zoneinfoStat{node: node, zone: zone, field: fields[0], value: value})
+	}
+	return stats, scanner.Err()
+}
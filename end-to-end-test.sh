@@ -7,14 +7,18 @@ enabled_collectors=$(cat << COLLECTORS
   bcache
   btrfs
   buddyinfo
+  cgroups
   conntrack
   cpu
   cpufreq
+  crypto
   diskstats
   drbd
   edac
   entropy
+  fibrechannel
   filefd
+  hugepages
   hwmon
   infiniband
   interrupts
@@ -24,30 +28,38 @@ enabled_collectors=$(cat << COLLECTORS
   mdadm
   meminfo
   meminfo_numa
+  modules
   mountstats
   netdev
   netstat
   nfs
   nfsd
+  pagetypeinfo
   pressure
   qdisc
   rapl
   schedstat
+  slabinfo
   sockstat
   stat
+  sysctl
   thermal_zone
   textfile
   bonding
   udp_queues 
   vmstat
+  watchdog
   wifi
+  wireless
   xfs
   zfs
+  zoneinfo
   processes
 COLLECTORS
 )
 disabled_collectors=$(cat << COLLECTORS
   filesystem
+  os
   time
   timex
   uname
@@ -58,7 +70,7 @@ cd "$(dirname $0)"
 port="$((10000 + (RANDOM % 10000)))"
 tmpdir=$(mktemp -d /tmp/node_exporter_e2e_test.XXXXXX)
 
-skip_re="^(go_|node_exporter_build_info|node_scrape_collector_duration_seconds|process_|node_textfile_mtime_seconds)"
+skip_re="^(go_|node_exporter_build_info|node_exporter_gc_duration_seconds|node_exporter_goroutines|node_scrape_collector_duration_seconds|process_|node_textfile_mtime_seconds)"
 
 arch="$(uname -m)"
 
@@ -107,6 +119,9 @@ fi
   --collector.qdisc.fixtures="collector/fixtures/qdisc/" \
   --collector.netclass.ignored-devices="(bond0|dmz|int)" \
   --collector.cpu.info \
+  --collector.sysctl.include="kernel.pid_max" \
+  --collector.sysctl.include="net/ipv4/tcp_rmem" \
+  --collector.sysctl.include="kernel.osrelease" \
   --collector.cpu.info.flags-include="^(aes|avx.?|constant_tsc)$" \
   --collector.cpu.info.bugs-include="^(cpu_meltdown|spectre_.*|mds)$" \
   --web.listen-address "127.0.0.1:${port}" \
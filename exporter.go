@@ -0,0 +1,64 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exporterCollector exposes Go runtime metrics of the exporter itself under
+// the program name, like the build_info metric of version.NewCollector.
+type exporterCollector struct {
+	goroutines *prometheus.Desc
+	gcDuration *prometheus.Desc
+}
+
+func newExporterCollector(program string) *exporterCollector {
+	return &exporterCollector{
+		goroutines: prometheus.NewDesc(
+			prometheus.BuildFQName(program, "", "goroutines"),
+			"Number of goroutines of "+program+".",
+			nil, nil,
+		),
+		gcDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(program, "", "gc_duration_seconds"),
+			"Summary of the pause duration of the garbage collection cycles of "+program+".",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *exporterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.goroutines
+	ch <- c.gcDuration
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *exporterCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.goroutines, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+
+	var stats debug.GCStats
+	stats.PauseQuantiles = make([]time.Duration, 5)
+	debug.ReadGCStats(&stats)
+	quantiles := make(map[float64]float64, len(stats.PauseQuantiles))
+	for i, pause := range stats.PauseQuantiles {
+		quantiles[float64(i)/float64(len(stats.PauseQuantiles)-1)] = pause.Seconds()
+	}
+	ch <- prometheus.MustNewConstSummary(c.gcDuration, uint64(stats.NumGC), stats.PauseTotal.Seconds(), quantiles)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestExporterCollector(t *testing.T) {
+	c := newExporterCollector("node_exporter")
+	if n := testutil.CollectAndCount(c); n != 2 {
+		t.Errorf("want 2 metrics, have %d", n)
+	}
+	problems, err := testutil.CollectAndLint(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		t.Errorf("lint problem with %s: %s", p.Metric, p.Text)
+	}
+}
+
+func TestExporterMetricsInHandler(t *testing.T) {
+	// The exporter metrics keep their name under another namespace and
+	// aren't reported as a collector.
+	if _, err := kingpin.CommandLine.Parse([]string{"--collector.namespace", "custom"}); err != nil {
+		t.Fatal(err)
+	}
+	defer kingpin.CommandLine.Parse([]string{})
+
+	c := contextCollector{ctxs: make(chan context.Context, 1)}
+	h := newTestHandler(0, c)
+	body := scrape(h, httptest.NewRequest("GET", "/metrics", nil)).Body.String()
+
+	for _, want := range []string{
+		"\nnode_exporter_build_info{",
+		"\nnode_exporter_goroutines ",
+		"\nnode_exporter_gc_duration_seconds_count ",
+		`custom_scrape_collector_success{collector="context"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want %q in output:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"custom_exporter_", `collector="exporter"`} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("want no %q in output:\n%s", unwanted, body)
+		}
+	}
+}
@@ -7,7 +7,7 @@ require (
 	github.com/ema/qdisc v0.0.0-20200603082823-62d0308e3e00
 	github.com/go-kit/kit v0.10.0
 	github.com/godbus/dbus v0.0.0-20190402143921-271e53dc4968
-	github.com/golang/protobuf v1.4.1 // indirect
+	github.com/golang/protobuf v1.4.1
 	github.com/hodgesds/perf-utils v0.0.8
 	github.com/jpillora/backoff v1.0.0 // indirect
 	github.com/lufia/iostat v1.1.0
@@ -14,11 +14,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/promlog/flag"
@@ -33,36 +36,55 @@ import (
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
-// handler wraps an unfiltered http.Handler but uses a filtered handler,
-// created on the fly, if filtering is requested. Create instances with
-// newHandler.
+// handler wraps an unfiltered NodeCollector but uses a filtered one, created
+// on the fly, if filtering is requested. Create instances with newHandler.
 type handler struct {
-	unfilteredHandler http.Handler
+	unfilteredCollector *collector.NodeCollector
 	// exporterMetricsRegistry is a separate registry for the metrics about
 	// the exporter itself.
 	exporterMetricsRegistry *prometheus.Registry
 	includeExporterMetrics  bool
+	enableOpenMetrics       bool
 	maxRequests             int
-	logger                  log.Logger
+	// inFlightSem limits the number of concurrent scrapes. It is nil if
+	// maxRequests is 0.
+	inFlightSem chan struct{}
+	logger      log.Logger
 }
 
-func newHandler(includeExporterMetrics bool, maxRequests int, logger log.Logger) *handler {
+func newHandler(includeExporterMetrics, enableOpenMetrics bool, maxRequests int, logger log.Logger) *handler {
 	h := &handler{
 		exporterMetricsRegistry: prometheus.NewRegistry(),
 		includeExporterMetrics:  includeExporterMetrics,
+		enableOpenMetrics:       enableOpenMetrics,
 		maxRequests:             maxRequests,
 		logger:                  logger,
 	}
+	if maxRequests > 0 {
+		h.inFlightSem = make(chan struct{}, maxRequests)
+	}
 	if h.includeExporterMetrics {
 		h.exporterMetricsRegistry.MustRegister(
 			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 			prometheus.NewGoCollector(),
 		)
 	}
-	if innerHandler, err := h.innerHandler(); err != nil {
-		panic(fmt.Sprintf("Couldn't create metrics handler: %s", err))
-	} else {
-		h.unfilteredHandler = innerHandler
+	nc, err := collector.NewNodeCollector(logger)
+	if err != nil {
+		panic(fmt.Sprintf("Couldn't create metrics handler: couldn't create collector: %s", err))
+	}
+	h.unfilteredCollector = nc
+
+	// Only log the enabled collectors of the unfiltered collector, which is
+	// created once upon startup.
+	level.Info(h.logger).Log("msg", "Enabled collectors")
+	collectors := []string{}
+	for n := range nc.Collectors {
+		collectors = append(collectors, n)
+	}
+	sort.Strings(collectors)
+	for _, c := range collectors {
+		level.Info(h.logger).Log("collector", c)
 	}
 	return h
 }
@@ -72,58 +94,67 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	filters := r.URL.Query()["collect[]"]
 	level.Debug(h.logger).Log("msg", "collect query:", "filters", filters)
 
-	if len(filters) == 0 {
-		// No filters, use the prepared unfiltered handler.
-		h.unfilteredHandler.ServeHTTP(w, r)
-		return
-	}
-	// To serve filtered metrics, we create a filtering handler on the fly.
-	filteredHandler, err := h.innerHandler(filters...)
-	if err != nil {
-		level.Warn(h.logger).Log("msg", "Couldn't create filtered metrics handler:", "err", err)
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(fmt.Sprintf("Couldn't create filtered metrics handler: %s", err)))
-		return
+	if h.inFlightSem != nil {
+		select {
+		case h.inFlightSem <- struct{}{}:
+			defer func() { <-h.inFlightSem }()
+		default:
+			http.Error(w, fmt.Sprintf(
+				"Limit of concurrent requests reached (%d), try again later.", h.maxRequests,
+			), http.StatusServiceUnavailable)
+			return
+		}
 	}
-	filteredHandler.ServeHTTP(w, r)
-}
 
-// innerHandler is used to create both the one unfiltered http.Handler to be
-// wrapped by the outer handler and also the filtered handlers created on the
-// fly. The former is accomplished by calling innerHandler without any arguments
-// (in which case it will log all the collectors enabled via command-line
-// flags).
-func (h *handler) innerHandler(filters ...string) (http.Handler, error) {
-	nc, err := collector.NewNodeCollector(h.logger, filters...)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't create collector: %s", err)
+	// Bind the scrape to the request, which is canceled when the client
+	// goes away, and to the scrape timeout announced by Prometheus.
+	ctx := r.Context()
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		if timeout, err := strconv.ParseFloat(v, 64); err == nil && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+			defer cancel()
+		}
 	}
 
-	// Only log the creation of an unfiltered handler, which should happen
-	// only once upon startup.
-	if len(filters) == 0 {
-		level.Info(h.logger).Log("msg", "Enabled collectors")
-		collectors := []string{}
-		for n := range nc.Collectors {
-			collectors = append(collectors, n)
-		}
-		sort.Strings(collectors)
-		for _, c := range collectors {
-			level.Info(h.logger).Log("collector", c)
+	nc := h.unfilteredCollector
+	if len(filters) > 0 {
+		// To serve filtered metrics, we create a filtering collector on the fly.
+		var err error
+		if nc, err = collector.NewNodeCollector(h.logger, filters...); err != nil {
+			level.Warn(h.logger).Log("msg", "Couldn't create filtered metrics handler:", "err", err)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Couldn't create filtered metrics handler: couldn't create collector: %s", err)))
+			return
 		}
 	}
+	innerHandler, err := h.innerHandler(nc.WithContext(ctx))
+	if err != nil {
+		level.Warn(h.logger).Log("msg", "Couldn't create metrics handler:", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf("Couldn't create metrics handler: %s", err)))
+		return
+	}
+	innerHandler.ServeHTTP(w, r)
+}
 
+// innerHandler creates the http.Handler serving a single scrape of nc
+// together with the metrics about the exporter itself.
+func (h *handler) innerHandler(nc *collector.NodeCollector) (http.Handler, error) {
 	r := prometheus.NewRegistry()
-	r.MustRegister(version.NewCollector("node_exporter"))
+	r.MustRegister(
+		version.NewCollector("node_exporter"),
+		newExporterCollector("node_exporter"),
+	)
 	if err := r.Register(nc); err != nil {
 		return nil, fmt.Errorf("couldn't register node collector: %s", err)
 	}
 	handler := promhttp.HandlerFor(
 		prometheus.Gatherers{h.exporterMetricsRegistry, r},
 		promhttp.HandlerOpts{
-			ErrorHandling:       promhttp.ContinueOnError,
-			MaxRequestsInFlight: h.maxRequests,
-			Registry:            h.exporterMetricsRegistry,
+			ErrorHandling:     promhttp.ContinueOnError,
+			Registry:          h.exporterMetricsRegistry,
+			EnableOpenMetrics: h.enableOpenMetrics,
 		},
 	)
 	if h.includeExporterMetrics {
@@ -150,6 +181,10 @@ func main() {
 			"web.disable-exporter-metrics",
 			"Exclude metrics about the exporter itself (promhttp_*, process_*, go_*).",
 		).Bool()
+		enableOpenMetrics = kingpin.Flag(
+			"web.enable-openmetrics",
+			"Serve OpenMetrics to scrapers asking for it, which includes exemplars from text files.",
+		).Bool()
 		maxRequests = kingpin.Flag(
 			"web.max-requests",
 			"Maximum number of parallel scrape requests. Use 0 to disable.",
@@ -177,7 +212,7 @@ func main() {
 	level.Info(logger).Log("msg", "Starting node_exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
 
-	http.Handle(*metricsPath, newHandler(!*disableExporterMetrics, *maxRequests, logger))
+	http.Handle(*metricsPath, newHandler(!*disableExporterMetrics, *enableOpenMetrics, *maxRequests, logger))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 			<head><title>Node Exporter</title></head>
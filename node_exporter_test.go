@@ -14,15 +14,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
 	"github.com/prometheus/procfs"
 )
 
@@ -152,3 +159,142 @@ func runCommandAndTests(cmd *exec.Cmd, address string, fn func(pid int) error) e
 	}
 	return err
 }
+
+// contextCollector sends the context of each update to ctxs and blocks until
+// release is closed, if set.
+type contextCollector struct {
+	ctxs    chan context.Context
+	release chan struct{}
+}
+
+func (c contextCollector) Update(ch chan<- prometheus.Metric) error {
+	return errors.New("Update called instead of UpdateContext")
+}
+
+func (c contextCollector) UpdateContext(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.ctxs <- ctx
+	if c.release != nil {
+		<-c.release
+	}
+	return ctx.Err()
+}
+
+// newTestHandler returns a handler whose only collector is c.
+func newTestHandler(maxRequests int, c collector.Collector) *handler {
+	collector.DisableDefaultCollectors()
+	h := newHandler(false, false, maxRequests, log.NewNopLogger())
+	h.unfilteredCollector.Collectors["context"] = c
+	return h
+}
+
+func scrape(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	return rw
+}
+
+func TestHandlerRegistryPerScrape(t *testing.T) {
+	c := contextCollector{ctxs: make(chan context.Context, 2)}
+	h := newTestHandler(0, c)
+
+	// Registering the same collectors twice in one registry would fail the
+	// second scrape.
+	for i := 0; i < 2; i++ {
+		rw := scrape(h, httptest.NewRequest("GET", "/metrics", nil))
+		if rw.Code != http.StatusOK {
+			t.Fatalf("scrape %d: want status %d, have %d. Body:\n%s", i, http.StatusOK, rw.Code, rw.Body)
+		}
+		for _, want := range []string{
+			"node_exporter_build_info{",
+			`node_scrape_collector_success{collector="context"} 1`,
+		} {
+			if !strings.Contains(rw.Body.String(), want) {
+				t.Errorf("scrape %d: want %q in output:\n%s", i, want, rw.Body)
+			}
+		}
+		<-c.ctxs
+	}
+}
+
+func TestHandlerScrapeTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		header  string
+		timeout time.Duration
+	}{
+		{header: ""},
+		{header: "2.5", timeout: 2500 * time.Millisecond},
+		{header: "10", timeout: 10 * time.Second},
+		{header: "0"},
+		{header: "-1"},
+		{header: "invalid"},
+	} {
+		t.Run(tc.header, func(t *testing.T) {
+			c := contextCollector{ctxs: make(chan context.Context, 1)}
+			h := newTestHandler(0, c)
+
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if tc.header != "" {
+				r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tc.header)
+			}
+			start := time.Now()
+			scrape(h, r)
+			end := time.Now()
+
+			deadline, ok := (<-c.ctxs).Deadline()
+			if tc.timeout == 0 {
+				if ok {
+					t.Errorf("want no deadline, have %s", deadline)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("want a deadline, have none")
+			}
+			if deadline.Before(start.Add(tc.timeout)) || deadline.After(end.Add(tc.timeout)) {
+				t.Errorf("want deadline %s after the scrape started, have %s", tc.timeout, deadline.Sub(start))
+			}
+		})
+	}
+}
+
+func TestHandlerCanceledScrape(t *testing.T) {
+	c := contextCollector{ctxs: make(chan context.Context, 1)}
+	h := newTestHandler(0, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rw := scrape(h, httptest.NewRequest("GET", "/metrics", nil).WithContext(ctx))
+
+	if err := (<-c.ctxs).Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("want the collector context to be canceled, have %v", err)
+	}
+	if want := `node_scrape_collector_success{collector="context"} 0`; !strings.Contains(rw.Body.String(), want) {
+		t.Errorf("want %q in output:\n%s", want, rw.Body)
+	}
+}
+
+func TestHandlerMaxRequests(t *testing.T) {
+	c := contextCollector{ctxs: make(chan context.Context, 2), release: make(chan struct{})}
+	h := newTestHandler(1, c)
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- scrape(h, httptest.NewRequest("GET", "/metrics", nil))
+	}()
+	// Wait until the first scrape holds the only slot.
+	<-c.ctxs
+
+	if rw := scrape(h, httptest.NewRequest("GET", "/metrics", nil)); rw.Code != http.StatusServiceUnavailable {
+		t.Errorf("want status %d for a scrape over the limit, have %d", http.StatusServiceUnavailable, rw.Code)
+	}
+
+	close(c.release)
+	if rw := <-first; rw.Code != http.StatusOK {
+		t.Errorf("want status %d for the first scrape, have %d", http.StatusOK, rw.Code)
+	}
+
+	// The slot is free again once the first scrape is done.
+	if rw := scrape(h, httptest.NewRequest("GET", "/metrics", nil)); rw.Code != http.StatusOK {
+		t.Errorf("want status %d after the first scrape finished, have %d", http.StatusOK, rw.Code)
+	}
+}